@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	puzzle "github.com/MaaXYZ/MaaEnd/agent/go-service/puzzle-solver"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// runOfflineCommand handles subcommands that work without the MAA framework.
+// It reports whether args named such a subcommand, and the process exit code if so.
+func runOfflineCommand(args []string) (bool, int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+
+	switch args[0] {
+	case "puzzle":
+		initConsoleLogger()
+		return true, runPuzzleCommand(args[1:])
+	default:
+		return false, 0
+	}
+}
+
+// initConsoleLogger sends logs to stderr so that stdout only carries command output
+func initConsoleLogger() {
+	log.Logger = zerolog.New(zerolog.ConsoleWriter{
+		Out:        os.Stderr,
+		TimeFormat: time.RFC3339,
+	}).With().Timestamp().Logger()
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+}
+
+const puzzleUsage = `Usage:
+  go-service puzzle solve [flags] <board.json>    solve a saved BoardDesc and print the placements
+  go-service puzzle replay [flags] <session>      recognize and solve a recorded session under debug/puzzle/ again
+  go-service puzzle generate [flags] <dir>        write random solvable boards into dir, e.g. as a benchmark corpus`
+
+func runPuzzleCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, puzzleUsage)
+		return 2
+	}
+	switch args[0] {
+	case "solve":
+		return runPuzzleSolve(args[1:])
+	case "replay":
+		return runPuzzleReplay(args[1:])
+	case "generate":
+		return runPuzzleGenerate(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, puzzleUsage)
+		return 2
+	}
+}
+
+func runPuzzleSolve(args []string) int {
+	fs := flag.NewFlagSet("puzzle solve", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print placements as JSON instead of text")
+	engine := fs.String("engine", string(puzzle.EngineBacktrack), "solver engine: backtrack or propagate")
+	timeout := fs.Duration("timeout", 0, "stop solving after this duration, 0 means no limit")
+	budget := fs.Int("budget", 0, "maximum search nodes to explore, 0 means no limit")
+	maxSolutions := fs.Int("max-solutions", 16, "maximum solutions to enumerate before choosing the cheapest")
+	workers := fs.Int("workers", 0, "parallel workers for the backtrack engine, 0 or 1 searches sequentially")
+	seed := fs.Int64("seed", 0, "order of the branches searched in parallel, 0 keeps the sequential order")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, puzzleUsage)
+		return 2
+	}
+
+	bd, err := puzzle.LoadBoardDesc(fs.Arg(0))
+	if err != nil {
+		log.Error().Err(err).Str("path", fs.Arg(0)).Msg("Failed to load board desc")
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result, err := puzzle.SolveAll(ctx, bd, puzzle.SolveOptions{
+		Engine:       puzzle.Engine(*engine),
+		NodeBudget:   *budget,
+		MaxSolutions: *maxSolutions,
+		Workers:      *workers,
+		Seed:         *seed,
+		Progress: func(p puzzle.SolveProgress) {
+			log.Info().Int("nodes", p.Nodes).Int("depth", p.Depth).Int("maxDepth", p.MaxDepth).
+				Dur("elapsed", p.Elapsed).Msg("Puzzle solver progress")
+		},
+	})
+	elapsed := time.Since(start)
+	if err != nil {
+		log.Error().Err(err).Dur("elapsed", elapsed).Msg("Failed to solve puzzle")
+		if errors.Is(err, puzzle.ErrNoSolution) {
+			if diagnoses, derr := puzzle.Diagnose(bd); derr == nil {
+				for _, d := range diagnoses {
+					fmt.Println("unsolvable:", d)
+				}
+			}
+		}
+		if board, rerr := puzzle.RenderBoardASCII(bd, nil); rerr == nil {
+			fmt.Print(board)
+		}
+		return 1
+	}
+	placements := result.Solutions[0]
+	log.Info().
+		Dur("elapsed", elapsed).
+		Int("pieces", len(placements)).
+		Int("solutions", len(result.Solutions)).
+		Bool("exhaustive", result.Exhaustive).
+		Float64("cost", result.Costs[0]).
+		Msg("Puzzle solved successfully")
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(placements); err != nil {
+			log.Error().Err(err).Msg("Failed to encode placements")
+			return 1
+		}
+		return 0
+	}
+
+	for _, p := range placements {
+		fmt.Printf("piece %d: x=%d y=%d rotation=%d\n", p.PuzzleIndex, p.MachineX, p.MachineY, p.Rotation)
+	}
+	board, err := puzzle.RenderBoardASCII(bd, placements)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render board")
+		return 1
+	}
+	fmt.Print(board)
+	return 0
+}
+
+func runPuzzleReplay(args []string) int {
+	fs := flag.NewFlagSet("puzzle replay", flag.ContinueOnError)
+	resourceDir := fs.String("resource", "resource", "resource directory holding the templates and palette, e.g. ../../assets/resource")
+	timeout := fs.Duration("timeout", 30*time.Second, "stop solving each board after this duration, 0 means no limit")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, puzzleUsage)
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	results, err := puzzle.ReplaySession(ctx, fs.Arg(0), *resourceDir, puzzle.SolveOptions{})
+	if err != nil {
+		log.Error().Err(err).Str("path", fs.Arg(0)).Msg("Failed to replay puzzle session")
+		return 1
+	}
+
+	code := 0
+	for _, r := range results {
+		fmt.Printf("== %s (tab %d, confidence %.2f)\n", r.Pass.Dir, r.Pass.Tab+1, r.Pass.Confidence)
+		if r.Pass.Error != "" {
+			fmt.Println("recorded error:", r.Pass.Error)
+		}
+		switch {
+		case r.BoardErr != nil:
+			fmt.Println("recognition:", r.BoardErr)
+			if r.Recorded != nil {
+				code = 1
+			}
+		case r.Recorded == nil:
+			fmt.Println("recognition: succeeded, nothing was recorded to compare with")
+		case r.BoardDiffers:
+			fmt.Println("recognition: differs from the recorded board")
+			code = 1
+		default:
+			fmt.Println("recognition: same as the recorded board")
+		}
+		if r.Recorded == nil {
+			continue
+		}
+		if r.SolveErr != nil {
+			fmt.Println("solve:", r.SolveErr)
+			code = 1
+			continue
+		}
+		for _, p := range r.Pass.Placements {
+			fmt.Printf("recorded piece %d: x=%d y=%d rotation=%d\n", p.PuzzleIndex, p.MachineX, p.MachineY, p.Rotation)
+		}
+		board, err := puzzle.RenderBoardASCII(r.Recorded, r.Placements)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to render board")
+			return 1
+		}
+		fmt.Print(board)
+	}
+	return code
+}
+
+func runPuzzleGenerate(args []string) int {
+	fs := flag.NewFlagSet("puzzle generate", flag.ContinueOnError)
+	preset := fs.String("preset", "medium", "board family: "+strings.Join(puzzle.GeneratePresetNames(), ", "))
+	count := fs.Int("n", 20, "number of boards to generate")
+	seed := fs.Int64("seed", 1, "random seed, the same seed generates the same boards")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	opts, ok := puzzle.GeneratePresets[*preset]
+	if fs.NArg() != 1 || !ok {
+		fmt.Fprintln(os.Stderr, puzzleUsage)
+		return 2
+	}
+
+	dir := fs.Arg(0)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Error().Err(err).Str("dir", dir).Msg("Failed to create output directory")
+		return 1
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	for i := range *count {
+		bd, _ := puzzle.GenerateBoard(rng, opts)
+		data, err := json.Marshal(bd)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to encode board")
+			return 1
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s_%d_%03d.json", *preset, *seed, i))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			log.Error().Err(err).Str("path", path).Msg("Failed to write board")
+			return 1
+		}
+		fmt.Println(path)
+	}
+	return 0
+}
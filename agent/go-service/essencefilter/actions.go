@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	maa "github.com/MaaXYZ/maa-framework-go/v4"
 	"github.com/rs/zerolog/log"
@@ -101,6 +102,10 @@ func (a *EssenceFilterInitAction) Run(ctx *maa.Context, arg *maa.CustomActionArg
 		log.Error().Str("preset", params.PresetName).Msg("<EssenceFilter> Step5 failed: preset not found")
 		return false
 	}
+	if err := ValidateFilterConfig(selectedPreset.Filter); err != nil {
+		log.Error().Err(err).Str("preset", params.PresetName).Msg("<EssenceFilter> Step5 failed: invalid preset")
+		return false
+	}
 
 	LogMXUSimpleHTML(ctx, fmt.Sprintf("已选择预设：%s", selectedPreset.Label))
 	// 6. filter weapons
@@ -133,9 +138,21 @@ func (a *EssenceFilterInitAction) Run(ctx *maa.Context, arg *maa.CustomActionArg
 	LogMXUHTML(ctx, builder.String())
 
 	// 7. extract combos
-	targetSkillCombinations = ExtractSkillCombinations(filteredWeapons)
+	targetSkillCombinations = ExtractSkillCombinations(filteredWeapons, selectedPreset.Filter.SkillRules)
+	targetFilter = selectedPreset.Filter
+	if targetFilter.MatchMode == MatchModeScore {
+		weights, threshold := scoreSettings(targetFilter)
+		LogMXUSimpleHTML(ctx, fmt.Sprintf("评分匹配：词条权重 %g / %g / %g，锁定阈值 %g", weights[0], weights[1], weights[2], threshold))
+	}
 	visitedCount = 0
 	matchedCount = 0
+	discardedCount = 0
+	discardLimitLogged = false
+	discardSummary = DiscardConfig{}
+	scannedItems = nil
+	currentItemPos = [2]int{}
+	inventoryPreset = selectedPreset.Name
+	inventoryStartedAt = time.Now()
 	matchedCombinationSummary = make(map[string]*SkillCombinationSummary)
 	currentCol = 1
 	currentRow = 1
@@ -148,7 +165,19 @@ func (a *EssenceFilterInitAction) Run(ctx *maa.Context, arg *maa.CustomActionArg
 
 	// 展示目标技能
 	var skillIdSlots [3][]int
+	var anySlots [3]bool
 	for _, c := range targetSkillCombinations {
+		if c.Rule != nil {
+			// 技能规则：展示允许的技能，未限制的槽位展示为“任意”
+			for i := range skillIdSlots {
+				if allowed := c.Rule.Slot(i + 1); len(allowed) > 0 {
+					skillIdSlots[i] = append(skillIdSlots[i], allowed...)
+				} else {
+					anySlots[i] = true
+				}
+			}
+			continue
+		}
 		for i, skillID := range c.SkillIDs {
 			skillIdSlots[i] = append(skillIdSlots[i], skillID)
 		}
@@ -173,6 +202,9 @@ func (a *EssenceFilterInitAction) Run(ctx *maa.Context, arg *maa.CustomActionArg
 			skillNames = append(skillNames, skillNameByID(id, pool))
 		}
 		sort.Strings(skillNames)
+		if anySlots[i] {
+			skillNames = append([]string{"任意"}, skillNames...)
+		}
 
 		if len(skillNames) == 0 {
 			continue
@@ -463,6 +495,8 @@ func (a *EssenceFilterRowNextItemAction) Run(ctx *maa.Context, arg *maa.CustomAc
 	}
 	ctx.RunTask("NodeClick", ClickingBoxOverrideParam)
 
+	// 尾扫时 rowBoxes 可能跨多行，按单行格子数换算
+	currentItemPos = [2]int{currentRow + rowIndex/maxItemsPerRow, rowIndex%maxItemsPerRow + 1}
 	visitedCount++
 	rowIndex++
 	ctx.OverrideNext(arg.CurrentTaskName, []maa.NodeNextItem{
@@ -477,7 +511,9 @@ type EssenceFilterSkillDecisionAction struct{}
 func (a *EssenceFilterSkillDecisionAction) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 	skills := []string{currentSkills[0], currentSkills[1], currentSkills[2]}
 
-	matchResult, matched := MatchEssenceSkills(ctx, skills)
+	skillIDs := resolveOCRSkillIDs(skills)
+	matchResult, matched := matchResolvedSkills(skills, skillIDs)
+	lockedAtScan, lockKnown := isCurrentItemLocked(ctx)
 	MatchedMessageColor := "#00bfff"
 	if matched {
 		MatchedMessageColor = "#064d7c"
@@ -516,6 +552,15 @@ func (a *EssenceFilterSkillDecisionAction) Run(ctx *maa.Context, arg *maa.Custom
 			`<div style="color: #064d7c; font-weight: 900;">匹配到武器：%s</div>`,
 			weaponsHTML.String(),
 		)
+		if len(matchResult.Weapons) == 0 {
+			MatchedMessage = `<div style="color: #064d7c; font-weight: 900;">匹配到技能规则</div>`
+		}
+		if matchResult.MatchedSlots != nil {
+			MatchedMessage += fmt.Sprintf(
+				`<div style="color: #064d7c;">得分：%s</div>`,
+				formatScore(matchResult.Score, matchResult.MatchedSlots),
+			)
+		}
 		LogMXUHTML(ctx, MatchedMessage)
 
 		// 更新本轮运行的技能组合统计信息
@@ -535,20 +580,32 @@ func (a *EssenceFilterSkillDecisionAction) Run(ctx *maa.Context, arg *maa.Custom
 					OCRSkills:     ocrSkillsCopy,
 					Weapons:       weaponsCopy,
 					Count:         1,
+					Score:         matchResult.Score,
+					MatchedSlots:  append([]bool(nil), matchResult.MatchedSlots...),
 				}
 			}
 		}
 
+		recordScannedItem(skills, skillIDs, true, lockedAtScan, lockKnown, true)
 		ctx.OverrideNext(arg.CurrentTaskName, []maa.NodeNextItem{
 			{Name: "EssenceFilterLockItemLog"},
 		})
 	} else {
-		log.Info().Strs("skills", skills).Msg("<EssenceFilter> not matched, skip to next item")
-		LogMXUSimpleHTML(ctx, "未匹配到目标技能组合，跳过该物品")
+		discard, err := parseDiscardConfig(arg.CustomActionParam)
+		if err != nil {
+			log.Error().Err(err).Str("param", arg.CustomActionParam).Msg("<EssenceFilter> invalid discard param, skip")
+			LogMXUSimpleHTMLWithColor(ctx, fmt.Sprintf("未匹配基质处理设置无效，按不处理跳过：%v", err), "#ff7000")
+		}
+		if discard.Mode == DiscardModeOff {
+			log.Info().Strs("skills", skills).Msg("<EssenceFilter> not matched, skip to next item")
+			LogMXUSimpleHTML(ctx, "未匹配到目标技能组合，跳过该物品")
+		}
+		discardSummary = discard
+		next := decideDiscard(ctx, discard, skills, skillIDs, lockedAtScan)
+		recordScannedItem(skills, skillIDs, false, lockedAtScan, lockKnown, lockedAtScan && next != discardNodes[DiscardModeUnlock])
 		ctx.OverrideNext(arg.CurrentTaskName, []maa.NodeNextItem{
-			{Name: "EssenceFilterRowNextItem"},
+			{Name: next},
 		})
-
 	}
 
 	currentSkills = [3]string{}
@@ -566,8 +623,23 @@ func (a *EssenceFilterFinishAction) Run(ctx *maa.Context, arg *maa.CustomActionA
 
 	// 追加本轮战利品摘要
 	logMatchSummary(ctx)
+	if discardSummary.Mode != DiscardModeOff {
+		preview := ""
+		if discardSummary.DryRun {
+			preview = "（预览，未实际操作）"
+		}
+		LogMXUSimpleHTMLWithColor(ctx, fmt.Sprintf("未匹配基质%s：%d 个%s", discardLabels[discardSummary.Mode], discardedCount, preview), "#ff7000")
+	}
+
+	saveInventoryAndReportDiff(ctx)
+	exportRunResults(arg.CustomActionParam)
 
 	targetSkillCombinations = nil
+	targetFilter = FilterConfig{}
+	scannedItems = nil
+	discardedCount = 0
+	discardLimitLogged = false
+	discardSummary = DiscardConfig{}
 	matchedCount = 0
 	visitedCount = 0
 	for i := range filteredSkillStats {
@@ -697,6 +769,17 @@ func formatWeaponNamesColoredHTML(weapons []WeaponData) string {
 	return b.String()
 }
 
+// formatScore - 评分模式下的得分及命中槽位，如 "2 (词条1、词条3)"
+func formatScore(score float64, matchedSlots []bool) string {
+	slots := make([]string, 0, len(matchedSlots))
+	for i, ok := range matchedSlots {
+		if ok {
+			slots = append(slots, fmt.Sprintf("词条%d", i+1))
+		}
+	}
+	return fmt.Sprintf("%g (%s)", score, strings.Join(slots, "、"))
+}
+
 // skillCombinationKey - 将技能 ID 列表转换为稳定的 key，用于统计 map
 func skillCombinationKey(ids []int) string {
 	if len(ids) == 0 {
@@ -733,10 +816,18 @@ func logMatchSummary(ctx *maa.Context) {
 	var b strings.Builder
 	b.WriteString(`<div style="color: #00bfff; font-weight: 900; margin-top: 4px;">战利品摘要：</div>`)
 	b.WriteString(`<table style="width: 100%; border-collapse: collapse; font-size: 12px;">`)
-	b.WriteString(`<tr><th style="text-align:left; padding: 2px 4px;">武器</th><th style="text-align:left; padding: 2px 4px;">技能组合</th><th style="text-align:right; padding: 2px 4px;">锁定数量</th></tr>`)
+	scored := targetFilter.MatchMode == MatchModeScore
+	b.WriteString(`<tr><th style="text-align:left; padding: 2px 4px;">武器</th><th style="text-align:left; padding: 2px 4px;">技能组合</th>`)
+	if scored {
+		b.WriteString(`<th style="text-align:left; padding: 2px 4px;">得分</th>`)
+	}
+	b.WriteString(`<th style="text-align:right; padding: 2px 4px;">锁定数量</th></tr>`)
 
 	for _, item := range items {
 		weaponText := formatWeaponNamesColoredHTML(item.Weapons)
+		if weaponText == "" {
+			weaponText = "（技能规则）"
+		}
 		// 为了和前面 OCR 日志一致，summary 优先展示实际 OCR 到的技能文本
 		skillSource := item.OCRSkills
 		if len(skillSource) == 0 {
@@ -747,6 +838,9 @@ func logMatchSummary(ctx *maa.Context) {
 		b.WriteString("<tr>")
 		b.WriteString(fmt.Sprintf(`<td style="padding: 2px 4px;">%s</td>`, weaponText))
 		b.WriteString(fmt.Sprintf(`<td style="padding: 2px 4px;">%s</td>`, skillText))
+		if scored {
+			b.WriteString(fmt.Sprintf(`<td style="padding: 2px 4px;">%s</td>`, formatScore(item.Score, item.MatchedSlots)))
+		}
 		b.WriteString(fmt.Sprintf(`<td style="padding: 2px 4px; text-align: right;">%d</td>`, item.Count))
 		b.WriteString("</tr>")
 	}
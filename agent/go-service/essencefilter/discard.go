@@ -0,0 +1,101 @@
+package essencefilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// 未匹配基质的处理方式（EssenceFilterSkillDecisionAction 的 discard_mode 参数）
+const (
+	DiscardModeOff    = ""       // 默认：跳过未匹配的基质
+	DiscardModeUnlock = "unlock" // 解除锁定（清理旧预设锁定的基质）
+	DiscardModeTrash  = "trash"  // 标记弃置：尚未录制弃置按钮模板，暂不支持，解析参数时报错
+)
+
+// defaultDiscardLimit - 未配置 discard_limit 时，单次运行最多处理的未匹配基质数量
+const defaultDiscardLimit = 20
+
+// DiscardConfig - EssenceFilterSkillDecisionAction 的参数，决定如何处理未匹配的基质
+type DiscardConfig struct {
+	Mode   string `json:"discard_mode"`  // DiscardModeOff / DiscardModeUnlock
+	Limit  int    `json:"discard_limit"` // safety cap per run, <= 0 means defaultDiscardLimit
+	DryRun bool   `json:"dry_run"`       // only preview, never route to the pipeline nodes
+}
+
+// discardNodes - 各处理方式对应的 Pipeline 入口节点
+var discardNodes = map[string]string{
+	DiscardModeUnlock: "EssenceFilterUnlockItemLog",
+}
+
+var discardLabels = map[string]string{
+	DiscardModeUnlock: "解除锁定",
+}
+
+// parseDiscardConfig - 解析参数，空参数或 discard_mode 为空时关闭
+func parseDiscardConfig(param string) (DiscardConfig, error) {
+	var config DiscardConfig
+	if param == "" {
+		return config, nil
+	}
+	if err := json.Unmarshal([]byte(param), &config); err != nil {
+		return DiscardConfig{}, err
+	}
+	if config.Mode == DiscardModeTrash {
+		return DiscardConfig{}, fmt.Errorf("discard_mode %q needs the trash button template, which is not recorded yet; use %q", config.Mode, DiscardModeUnlock)
+	}
+	if config.Mode != DiscardModeOff && discardNodes[config.Mode] == "" {
+		return DiscardConfig{}, fmt.Errorf("unknown discard_mode %q", config.Mode)
+	}
+	if config.Limit <= 0 {
+		config.Limit = defaultDiscardLimit
+	}
+	return config, nil
+}
+
+// decideDiscard - 决定未匹配基质的下一个节点；关闭、预览、达到上限，或处理后状态不会改变时返回 EssenceFilterRowNextItem。
+// 任一词条未映射到技能（ID 为 0）时可能只是 OCR 失误，该基质一律跳过
+func decideDiscard(ctx *maa.Context, config DiscardConfig, skills []string, skillIDs []int, locked bool) string {
+	const skipNode = "EssenceFilterRowNextItem"
+	if config.Mode == DiscardModeOff {
+		return skipNode
+	}
+	label := discardLabels[config.Mode]
+
+	if slices.Contains(skillIDs, 0) {
+		log.Warn().Str("mode", config.Mode).Strs("skills", skills).Ints("skill_ids", skillIDs).Msg("<EssenceFilter> skills not fully recognized, never discard")
+		LogMXUSimpleHTMLWithColor(ctx, fmt.Sprintf("词条未能全部识别，不%s该基质", label), "#ff7000")
+		return skipNode
+	}
+	if config.Mode == DiscardModeUnlock && !locked {
+		log.Info().Strs("skills", skills).Msg("<EssenceFilter> not matched and not locked, nothing to unlock")
+		return skipNode
+	}
+
+	if discardedCount >= config.Limit {
+		if !discardLimitLogged {
+			discardLimitLogged = true
+			log.Warn().Str("mode", config.Mode).Int("limit", config.Limit).Msg("<EssenceFilter> discard limit reached, skip the rest")
+			LogMXUSimpleHTMLWithColor(ctx, fmt.Sprintf("已达到%s数量上限 %d，其余未匹配基质将跳过", label, config.Limit), "#ff7000")
+		}
+		return skipNode
+	}
+	discardedCount++
+
+	log.Info().
+		Str("mode", config.Mode).
+		Bool("dry_run", config.DryRun).
+		Strs("skills", skills).
+		Int("discarded_count", discardedCount).
+		Int("limit", config.Limit).
+		Msg("<EssenceFilter> not matched, discard")
+	if config.DryRun {
+		LogMXUSimpleHTMLWithColor(ctx, fmt.Sprintf("[预览] 将%s该基质（%d/%d）", label, discardedCount, config.Limit), "#ff7000")
+		return skipNode
+	}
+	LogMXUSimpleHTMLWithColor(ctx, fmt.Sprintf("未匹配目标技能组合，%s该基质（%d/%d）", label, discardedCount, config.Limit), "#ff7000")
+	return discardNodes[config.Mode]
+}
@@ -0,0 +1,30 @@
+package essencefilter
+
+import "testing"
+
+func TestParseDiscardConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		param   string
+		want    DiscardConfig
+		wantErr bool
+	}{
+		{"empty param", "", DiscardConfig{}, false},
+		{"mode off", `{"discard_mode": ""}`, DiscardConfig{Limit: defaultDiscardLimit}, false},
+		{"unlock with default limit", `{"discard_mode": "unlock"}`, DiscardConfig{Mode: DiscardModeUnlock, Limit: defaultDiscardLimit}, false},
+		{"unlock with limit", `{"discard_mode": "unlock", "discard_limit": 5, "dry_run": true}`, DiscardConfig{Mode: DiscardModeUnlock, Limit: 5, DryRun: true}, false},
+		{"negative limit", `{"discard_mode": "unlock", "discard_limit": -1}`, DiscardConfig{Mode: DiscardModeUnlock, Limit: defaultDiscardLimit}, false},
+		{"trash without template", `{"discard_mode": "trash"}`, DiscardConfig{}, true},
+		{"unknown mode", `{"discard_mode": "delete"}`, DiscardConfig{}, true},
+		{"broken json", `{"discard_mode":`, DiscardConfig{}, true},
+	} {
+		got, err := parseDiscardConfig(tc.param)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: error %v, want error %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package essencefilter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 运行结果导出：默认写到 debug/essencefilter/ 下，EssenceFilterFinishAction 的 export_dir 参数可指定其他目录
+const exportTimeFormat = "20060102-150405"
+
+var defaultExportDir = filepath.Join(".", "debug", "essencefilter")
+
+// ExportConfig - EssenceFilterFinishAction 的参数
+type ExportConfig struct {
+	Disabled bool   `json:"export_disabled"` // true 时不导出
+	Dir      string `json:"export_dir"`      // 导出目录，为空时为 debug/essencefilter
+}
+
+// RunReport - 一次运行的结果，同时写成 JSON 与 CSV
+type RunReport struct {
+	Preset     string              `json:"preset"`
+	StartedAt  time.Time           `json:"started_at"`
+	FinishedAt time.Time           `json:"finished_at"`
+	Visited    int                 `json:"visited"`
+	Matched    int                 `json:"matched"`
+	Combos     []ReportCombination `json:"matched_combinations"`
+	Unmatched  []ReportItem        `json:"unmatched"`
+}
+
+// ReportCombination - 命中的一套技能组合，对应 SkillCombinationSummary
+type ReportCombination struct {
+	SkillIDs      []int    `json:"skill_ids"`
+	SkillsChinese []string `json:"skills_chinese"`
+	OCRSkills     []string `json:"ocr_skills"`
+	Weapons       []string `json:"weapons"`       // 武器中文名
+	WeaponIDs     []string `json:"weapon_ids"`    // 武器 internal_id
+	Count         int      `json:"count"`         // 锁定数量
+	Score         float64  `json:"score"`         // 评分模式下的得分
+	MatchedSlots  []bool   `json:"matched_slots"` // 评分模式下命中的槽位
+}
+
+// ReportItem - 未匹配的基质，技能 ID 为 OCR 映射的最佳猜测，未识别的槽位为 0
+type ReportItem struct {
+	OCRSkills     []string `json:"ocr_skills"`
+	SkillIDs      []int    `json:"skill_ids"`
+	SkillsChinese []string `json:"skills_chinese"`
+	Row           int      `json:"row"`
+	Col           int      `json:"col"`
+	Locked        bool     `json:"locked"`
+}
+
+// buildRunReport - 汇总本次运行的计数、命中组合与未匹配基质
+func buildRunReport() *RunReport {
+	report := &RunReport{
+		Preset:     inventoryPreset,
+		StartedAt:  inventoryStartedAt,
+		FinishedAt: time.Now(),
+		Visited:    visitedCount,
+		Matched:    matchedCount,
+		Combos:     []ReportCombination{},
+		Unmatched:  []ReportItem{},
+	}
+
+	keys := make([]string, 0, len(matchedCombinationSummary))
+	for k := range matchedCombinationSummary {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := matchedCombinationSummary[k]
+		combo := ReportCombination{
+			SkillIDs:      s.SkillIDs,
+			SkillsChinese: s.SkillsChinese,
+			OCRSkills:     s.OCRSkills,
+			Weapons:       []string{},
+			WeaponIDs:     []string{},
+			Count:         s.Count,
+			Score:         s.Score,
+			MatchedSlots:  s.MatchedSlots,
+		}
+		for _, w := range s.Weapons {
+			combo.Weapons = append(combo.Weapons, w.ChineseName)
+			combo.WeaponIDs = append(combo.WeaponIDs, w.InternalID)
+		}
+		report.Combos = append(report.Combos, combo)
+	}
+
+	for _, item := range scannedItems {
+		if item.Matched {
+			continue
+		}
+		report.Unmatched = append(report.Unmatched, ReportItem{
+			OCRSkills:     item.OCRSkills,
+			SkillIDs:      item.SkillIDs,
+			SkillsChinese: ocrSkillNames(item.SkillIDs),
+			Row:           item.Row,
+			Col:           item.Col,
+			Locked:        item.Locked,
+		})
+	}
+	return report
+}
+
+// exportRunReport - 写出 essence_filter_<时间>.json 与 .csv，返回 JSON 文件路径
+func exportRunReport(config ExportConfig, report *RunReport) (string, error) {
+	dir := config.Dir
+	if dir == "" {
+		dir = defaultExportDir
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	base := filepath.Join(dir, "essence_filter_"+report.FinishedAt.Format(exportTimeFormat))
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(base+".json", data, 0644); err != nil {
+		return "", err
+	}
+	if err := writeRunReportCSV(base+".csv", report); err != nil {
+		return "", err
+	}
+	return base + ".json", nil
+}
+
+// writeRunReportCSV - 每个命中组合与未匹配基质各一行；带 UTF-8 BOM，便于表格软件识别中文。
+// 关闭文件失败（如磁盘已满）也返回错误，避免把写了一半的文件当作导出成功
+func writeRunReportCSV(path string, report *RunReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeRunReportRows(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeRunReportRows(out io.Writer, report *RunReport) error {
+	if _, err := io.WriteString(out, "\ufeff"); err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(out)
+	header := []string{"type", "count", "weapons", "weapon_ids", "score", "matched_slots", "row", "col", "locked"}
+	for slot := 1; slot <= 3; slot++ {
+		header = append(header, fmt.Sprintf("slot%d_ocr", slot), fmt.Sprintf("slot%d_skill_id", slot), fmt.Sprintf("slot%d_skill", slot))
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	slotColumns := func(ocr []string, ids []int, names []string) []string {
+		var cols []string
+		for i := range 3 {
+			cols = append(cols, indexOr(ocr, i), strconv.Itoa(indexOr(ids, i)), indexOr(names, i))
+		}
+		return cols
+	}
+	for _, c := range report.Combos {
+		slots := make([]string, 0, len(c.MatchedSlots))
+		for i, ok := range c.MatchedSlots {
+			if ok {
+				slots = append(slots, strconv.Itoa(i+1))
+			}
+		}
+		row := []string{
+			"matched", strconv.Itoa(c.Count),
+			strings.Join(c.Weapons, "|"), strings.Join(c.WeaponIDs, "|"),
+			strconv.FormatFloat(c.Score, 'g', -1, 64), strings.Join(slots, "|"),
+			"", "", "",
+		}
+		if err := w.Write(append(row, slotColumns(c.OCRSkills, c.SkillIDs, c.SkillsChinese)...)); err != nil {
+			return err
+		}
+	}
+	for _, item := range report.Unmatched {
+		row := []string{
+			"unmatched", "1", "", "", "", "",
+			strconv.Itoa(item.Row), strconv.Itoa(item.Col), strconv.FormatBool(item.Locked),
+		}
+		if err := w.Write(append(row, slotColumns(item.OCRSkills, item.SkillIDs, item.SkillsChinese)...)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func indexOr[T any](s []T, i int) T {
+	var zero T
+	if i < len(s) {
+		return s[i]
+	}
+	return zero
+}
+
+// exportRunResults - 按参数导出本次运行结果，失败只记录日志
+func exportRunResults(param string) {
+	var config ExportConfig
+	if param != "" {
+		if err := json.Unmarshal([]byte(param), &config); err != nil {
+			log.Warn().Err(err).Str("param", param).Msg("<EssenceFilter> export: invalid param, use defaults")
+			config = ExportConfig{}
+		}
+	}
+	if config.Disabled {
+		return
+	}
+	path, err := exportRunReport(config, buildRunReport())
+	if err != nil {
+		log.Error().Err(err).Str("dir", config.Dir).Msg("<EssenceFilter> export failed")
+		return
+	}
+	log.Info().Str("path", path).Msg("<EssenceFilter> run results exported")
+}
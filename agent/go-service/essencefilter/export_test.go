@@ -0,0 +1,60 @@
+package essencefilter
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteRunReportCSV(t *testing.T) {
+	report := &RunReport{
+		Combos: []ReportCombination{{
+			SkillIDs:      []int{1, 10, 20},
+			SkillsChinese: []string{"攻击", "暴击", "强攻"},
+			OCRSkills:     []string{"攻击提升", "暴击提升", "强攻"},
+			Weapons:       []string{"甲", "乙"},
+			WeaponIDs:     []string{"a", "b"},
+			Count:         2,
+			Score:         2.5,
+			MatchedSlots:  []bool{true, false, true},
+		}},
+		Unmatched: []ReportItem{{
+			OCRSkills: []string{"攻击提升", "???", "残暴"},
+			SkillIDs:  []int{1, 0, 21},
+			Row:       3,
+			Col:       4,
+			Locked:    true,
+		}},
+	}
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := writeRunReportCSV(path, report); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, ok := strings.CutPrefix(string(data), "\ufeff")
+	if !ok {
+		t.Error("no UTF-8 BOM")
+	}
+	rows, err := csv.NewReader(strings.NewReader(text)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"type", "count", "weapons", "weapon_ids", "score", "matched_slots", "row", "col", "locked",
+			"slot1_ocr", "slot1_skill_id", "slot1_skill", "slot2_ocr", "slot2_skill_id", "slot2_skill", "slot3_ocr", "slot3_skill_id", "slot3_skill"},
+		{"matched", "2", "甲|乙", "a|b", "2.5", "1|3", "", "", "",
+			"攻击提升", "1", "攻击", "暴击提升", "10", "暴击", "强攻", "20", "强攻"},
+		{"unmatched", "1", "", "", "", "", "3", "4", "true",
+			"攻击提升", "1", "", "???", "0", "", "残暴", "21", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("csv rows\n got: %q\nwant: %q", rows, want)
+	}
+}
@@ -1,10 +1,52 @@
 package essencefilter
 
+import (
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ValidateFilterConfig - 检查预设的过滤配置，拒绝会锁定整个背包的技能规则、未知的匹配方式，
+// 以及无效的评分权重与永远达不到的阈值
+func ValidateFilterConfig(config FilterConfig) error {
+	for i, r := range config.SkillRules {
+		if len(r.Slot1) == 0 && len(r.Slot2) == 0 && len(r.Slot3) == 0 {
+			return fmt.Errorf("skill_rules[%d]: all slots are empty, the rule would match every essence", i)
+		}
+	}
+	switch config.MatchMode {
+	case "", MatchModeExact, MatchModeScore:
+	default:
+		return fmt.Errorf("match_mode: unknown mode %q, want %q or %q", config.MatchMode, MatchModeExact, MatchModeScore)
+	}
+	if len(config.SlotWeights) != 0 && len(config.SlotWeights) != 3 {
+		return fmt.Errorf("slot_weights: %d weights, want one per slot (3)", len(config.SlotWeights))
+	}
+	for i, w := range config.SlotWeights {
+		if w < 0 {
+			return fmt.Errorf("slot_weights[%d]: negative weight %g", i, w)
+		}
+	}
+	if weights, threshold := scoreSettings(config); threshold > weights[0]+weights[1]+weights[2] {
+		return fmt.Errorf("score_threshold: %g is above the total slot weight %g, nothing could be locked", threshold, weights[0]+weights[1]+weights[2])
+	}
+	return nil
+}
+
 // FilterWeaponsByConfig - 根据配置过滤武器
 func FilterWeaponsByConfig(config FilterConfig) []WeaponData {
 	result := []WeaponData{}
 
 	for _, weapon := range weaponDB.Weapons {
+		// 指定武器 / 排除武器
+		if len(config.WeaponIDs) > 0 && !slices.Contains(config.WeaponIDs, weapon.InternalID) {
+			continue
+		}
+		if slices.Contains(config.ExcludeWeaponIDs, weapon.InternalID) {
+			continue
+		}
+
 		// 类型过滤
 		if len(config.TypeIDs) > 0 {
 			matched := false
@@ -33,17 +75,108 @@ func FilterWeaponsByConfig(config FilterConfig) []WeaponData {
 	return result
 }
 
-// ExtractSkillCombinations - 提取技能组合
-func ExtractSkillCombinations(weapons []WeaponData) []SkillCombination {
+// ExtractSkillCombinations - 提取技能组合：每把武器一条，另外每条技能规则一条。
+// 规则限定的槽位只保留过滤后的武器在该槽位拥有的技能，与这些武器无交集的规则被忽略
+func ExtractSkillCombinations(weapons []WeaponData, rules []SkillRule) []SkillCombination {
 	combinations := []SkillCombination{}
 
+	var weaponSkills [3][]int
 	for _, weapon := range weapons {
 		combinations = append(combinations, SkillCombination{
 			Weapon:        weapon,
 			SkillsChinese: weapon.SkillsChinese,
 			SkillIDs:      weapon.SkillIDs,
 		})
+		for i, id := range weapon.SkillIDs {
+			if i < len(weaponSkills) && !slices.Contains(weaponSkills[i], id) {
+				weaponSkills[i] = append(weaponSkills[i], id)
+			}
+		}
+	}
+	for i, rule := range rules {
+		restricted, ok := rule.restrictTo(weaponSkills)
+		if !ok {
+			log.Warn().Int("rule", i).Ints("slot1", rule.Slot1).Ints("slot2", rule.Slot2).Ints("slot3", rule.Slot3).
+				Msg("[EssenceFilter] 技能规则与所选武器的技能无交集，已忽略")
+			continue
+		}
+		combinations = append(combinations, SkillCombination{Rule: &restricted})
 	}
 
 	return combinations
 }
+
+// restrictTo - 将规则限定的槽位与各槽位可用的技能取交集，未限定的槽位保持任意；
+// 任一限定槽位交集为空时规则不可能命中，返回 false
+func (r SkillRule) restrictTo(slotSkills [3][]int) (SkillRule, bool) {
+	var restricted [3][]int
+	for i := range restricted {
+		allowed := r.Slot(i + 1)
+		if len(allowed) == 0 {
+			continue
+		}
+		for _, id := range allowed {
+			if slices.Contains(slotSkills[i], id) {
+				restricted[i] = append(restricted[i], id)
+			}
+		}
+		if len(restricted[i]) == 0 {
+			return SkillRule{}, false
+		}
+	}
+	return SkillRule{Slot1: restricted[0], Slot2: restricted[1], Slot3: restricted[2]}, true
+}
+
+// Matches - 三个词条的技能 ID 是否满足该组合：武器组合要求完全一致，规则组合按规则判断
+func (c SkillCombination) Matches(ids []int) bool {
+	if c.Rule != nil {
+		return c.Rule.Matches(ids)
+	}
+	return len(ids) == 3 && slices.Equal(c.SkillIDs, ids)
+}
+
+// SlotMatches - 第 slot 个词条（1-3）的技能 ID 是否命中该组合；规则未限定的槽位不算命中，不参与评分
+func (c SkillCombination) SlotMatches(slot, id int) bool {
+	if c.Rule != nil {
+		return slices.Contains(c.Rule.Slot(slot), id)
+	}
+	return slot >= 1 && slot <= len(c.SkillIDs) && c.SkillIDs[slot-1] == id
+}
+
+// MaxScore - 该组合在评分模式下可得的最高分，即其限定的槽位的权重之和
+func (c SkillCombination) MaxScore(weights [3]float64) float64 {
+	score := 0.0
+	for i, w := range weights {
+		if c.Rule == nil || len(c.Rule.Slot(i+1)) > 0 {
+			score += w
+		}
+	}
+	return score
+}
+
+// Slot - 第 slot 个词条（1-3）允许的技能 ID，为空表示任意
+func (r SkillRule) Slot(slot int) []int {
+	switch slot {
+	case 1:
+		return r.Slot1
+	case 2:
+		return r.Slot2
+	case 3:
+		return r.Slot3
+	default:
+		return nil
+	}
+}
+
+// Matches - 三个词条的技能 ID 是否都在规则允许的范围内
+func (r SkillRule) Matches(ids []int) bool {
+	if len(ids) != 3 {
+		return false
+	}
+	for i, id := range ids {
+		if allowed := r.Slot(i + 1); len(allowed) > 0 && !slices.Contains(allowed, id) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package essencefilter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSkillRuleMatches(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rule SkillRule
+		ids  []int
+		want bool
+	}{
+		{"all slots allowed", SkillRule{Slot1: []int{3}, Slot2: []int{5}, Slot3: []int{1, 2}}, []int{3, 5, 2}, true},
+		{"one slot not allowed", SkillRule{Slot1: []int{3}, Slot2: []int{5}, Slot3: []int{1, 2}}, []int{3, 5, 4}, false},
+		{"empty slots match anything", SkillRule{Slot1: []int{3}}, []int{3, 9, 9}, true},
+		{"constrained slot missed", SkillRule{Slot1: []int{3}}, []int{4, 9, 9}, false},
+		{"unrecognized slot", SkillRule{Slot3: []int{1}}, []int{3, 5, 0}, false},
+		{"too few skills", SkillRule{Slot1: []int{3}}, []int{3, 5}, false},
+		{"no skills", SkillRule{}, nil, false},
+	} {
+		if got := tc.rule.Matches(tc.ids); got != tc.want {
+			t.Errorf("%s: %+v.Matches(%v) = %v, want %v", tc.name, tc.rule, tc.ids, got, tc.want)
+		}
+	}
+}
+
+func TestExtractSkillCombinationsRestrictsRules(t *testing.T) {
+	weapons := []WeaponData{
+		{InternalID: "a", SkillIDs: []int{1, 10, 20}},
+		{InternalID: "b", SkillIDs: []int{2, 11, 20}},
+	}
+	rules := []SkillRule{
+		{Slot1: []int{1, 3}, Slot3: []int{20, 21}}, // 保留 {1} 与 {20}，词条 2 仍不限
+		{Slot2: []int{12}},                         // 与所选武器无交集，忽略
+	}
+	combos := ExtractSkillCombinations(weapons, rules)
+	if len(combos) != 3 {
+		t.Fatalf("%d combinations, want 2 weapons and 1 rule", len(combos))
+	}
+	want := SkillRule{Slot1: []int{1}, Slot3: []int{20}}
+	if got := combos[2].Rule; got == nil || !reflect.DeepEqual(*got, want) {
+		t.Errorf("restricted rule %+v, want %+v", got, want)
+	}
+	if rules[0].Slot1[1] != 3 {
+		t.Errorf("preset rule modified: %+v", rules[0])
+	}
+}
+
+func TestValidateFilterConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  FilterConfig
+		wantErr bool
+	}{
+		{"default", FilterConfig{}, false},
+		{"score mode", FilterConfig{MatchMode: MatchModeScore, SkillRules: []SkillRule{{Slot2: []int{4}}}}, false},
+		{"empty rule", FilterConfig{SkillRules: []SkillRule{{Slot1: []int{1}}, {}}}, true},
+		{"unknown match mode", FilterConfig{MatchMode: "scored"}, true},
+		{"weights and threshold", FilterConfig{MatchMode: MatchModeScore, SlotWeights: []float64{2, 1, 0}, ScoreThreshold: 3}, false},
+		{"too few weights", FilterConfig{MatchMode: MatchModeScore, SlotWeights: []float64{2, 1}}, true},
+		{"negative weight", FilterConfig{MatchMode: MatchModeScore, SlotWeights: []float64{2, -1, 1}}, true},
+		{"threshold above total weight", FilterConfig{MatchMode: MatchModeScore, ScoreThreshold: 3.5}, true},
+		{"threshold above given weights", FilterConfig{MatchMode: MatchModeScore, SlotWeights: []float64{1, 1, 0.5}, ScoreThreshold: 3}, true},
+	} {
+		if err := ValidateFilterConfig(tc.config); (err != nil) != tc.wantErr {
+			t.Errorf("%s: error %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package essencefilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// 基质库存记录：用户目录（agent 工作目录）下 cache/essencefilter/inventory.json，保存最近一次完整运行扫描到的所有基质
+// 版本号在字段增加或含义变化时递增，早于 inventoryMinVersion 的记录不参与对比。
+// 版本 2 增加了 matched：版本 1 的记录读取后均为未匹配，对比不使用该字段，仍可读取
+const (
+	inventoryVersion    = 2
+	inventoryMinVersion = 1
+)
+
+var inventoryPath = filepath.Join(".", "cache", "essencefilter", "inventory.json")
+
+// inventoryDiffShowMax - MXU 中每类变化最多展示的条数，完整列表见日志
+const inventoryDiffShowMax = 10
+
+// InventoryItem - 一个扫描到的基质
+type InventoryItem struct {
+	OCRSkills    []string  `json:"ocr_skills"`             // [slot1, slot2, slot3] OCR 原文
+	SkillIDs     []int     `json:"skill_ids"`              // 映射得到的技能 ID，未识别的槽位为 0
+	Row          int       `json:"row"`                    // 第几行（从 1 开始，按滑动计数）
+	Col          int       `json:"col"`                    // 行内第几个（从 1 开始）
+	Matched      bool      `json:"matched"`                // 是否匹配目标技能组合，版本 2 起记录
+	LockedAtScan bool      `json:"locked_at_scan"`         // 扫描时是否已锁定
+	LockUnknown  bool      `json:"lock_unknown,omitempty"` // 扫描时未能识别锁定状态，LockedAtScan 为 false，不参与锁定状态对比
+	Locked       bool      `json:"locked"`                 // 本次运行处理后是否锁定
+	ScannedAt    time.Time `json:"scanned_at"`
+}
+
+// InventorySnapshot - 一次运行的库存记录
+type InventorySnapshot struct {
+	Version    int             `json:"version"`
+	Preset     string          `json:"preset"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Items      []InventoryItem `json:"items"`
+}
+
+// InventoryLockChange - 两次运行之间锁定状态发生变化的基质
+type InventoryLockChange struct {
+	Previous InventoryItem `json:"previous"`
+	Current  InventoryItem `json:"current"`
+}
+
+// InventoryDiff - 本次扫描与上次记录的差异
+type InventoryDiff struct {
+	New         []InventoryItem       `json:"new"`
+	Gone        []InventoryItem       `json:"gone"`
+	LockChanged []InventoryLockChange `json:"lock_changed"`
+}
+
+// inventoryItemKey - 基质的身份：技能都识别出时用技能 ID，否则用 OCR 原文。
+// 基质没有唯一编号，相同词条的基质视为可互换。
+func inventoryItemKey(item InventoryItem) string {
+	resolved := len(item.SkillIDs) == 3
+	for _, id := range item.SkillIDs {
+		resolved = resolved && id != 0
+	}
+	if resolved {
+		return "id:" + skillCombinationKey(item.SkillIDs)
+	}
+	return "ocr:" + strings.Join(item.OCRSkills, "|")
+}
+
+// DiffInventory - 按 inventoryItemKey 配对两次记录，同一身份的基质优先与位置相同的配对。
+// 锁定状态的变化比较上次运行结束时的状态与本次扫描时的状态，即两次运行之间在游戏内做的改动。
+func DiffInventory(previous, current []InventoryItem) InventoryDiff {
+	pending := make(map[string][]int)
+	for i, item := range previous {
+		key := inventoryItemKey(item)
+		pending[key] = append(pending[key], i)
+	}
+
+	var diff InventoryDiff
+	for _, cur := range current {
+		key := inventoryItemKey(cur)
+		candidates := pending[key]
+		if len(candidates) == 0 {
+			diff.New = append(diff.New, cur)
+			continue
+		}
+		pick := 0
+		for j, i := range candidates {
+			if previous[i].Row == cur.Row && previous[i].Col == cur.Col {
+				pick = j
+				break
+			}
+		}
+		prev := previous[candidates[pick]]
+		pending[key] = append(candidates[:pick], candidates[pick+1:]...)
+		if !prev.LockUnknown && !cur.LockUnknown && prev.Locked != cur.LockedAtScan {
+			diff.LockChanged = append(diff.LockChanged, InventoryLockChange{Previous: prev, Current: cur})
+		}
+	}
+	for i, item := range previous {
+		for _, j := range pending[inventoryItemKey(item)] {
+			if j == i {
+				diff.Gone = append(diff.Gone, item)
+				break
+			}
+		}
+	}
+	return diff
+}
+
+// LoadInventorySnapshot - 读取上次的库存记录，不存在时返回 nil
+func LoadInventorySnapshot(path string) (*InventorySnapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshot InventorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.Version < inventoryMinVersion || snapshot.Version > inventoryVersion {
+		return nil, fmt.Errorf("inventory version %d, want %d to %d", snapshot.Version, inventoryMinVersion, inventoryVersion)
+	}
+	return &snapshot, nil
+}
+
+// SaveInventorySnapshot - 写入本次的库存记录，覆盖上次的
+func SaveInventorySnapshot(path string, snapshot *InventorySnapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// recordScannedItem - 记录当前格子的基质；lockKnown 为扫描时是否识别出了锁定状态，locked 为本次运行处理后的锁定状态
+func recordScannedItem(ocrSkills []string, skillIDs []int, matched, lockedAtScan, lockKnown, locked bool) {
+	scannedItems = append(scannedItems, InventoryItem{
+		OCRSkills:    append([]string(nil), ocrSkills...),
+		SkillIDs:     append([]int(nil), skillIDs...),
+		Row:          currentItemPos[0],
+		Col:          currentItemPos[1],
+		Matched:      matched,
+		LockedAtScan: lockedAtScan,
+		LockUnknown:  !lockKnown,
+		Locked:       locked,
+		ScannedAt:    time.Now(),
+	})
+}
+
+// isCurrentItemLocked - 用最近一次截图（识别词条时的详情界面）判断当前基质是否已锁定；
+// 截图或识别失败时 ok 为 false，锁定状态未知
+func isCurrentItemLocked(ctx *maa.Context) (locked, ok bool) {
+	controller := ctx.GetTasker().GetController()
+	if controller == nil {
+		return false, false
+	}
+	img, err := controller.CacheImage()
+	if err != nil {
+		log.Warn().Err(err).Msg("<EssenceFilter> inventory: get screenshot failed")
+		return false, false
+	}
+	detail, err := ctx.RunRecognition("EssenceFilterCheckLocked", img)
+	if err != nil {
+		log.Warn().Err(err).Msg("<EssenceFilter> inventory: lock state recognition failed")
+		return false, false
+	}
+	return detail != nil && detail.Hit, true
+}
+
+// saveInventoryAndReportDiff - 与上次记录对比并展示差异，然后保存本次记录
+func saveInventoryAndReportDiff(ctx *maa.Context) {
+	if len(scannedItems) == 0 {
+		return
+	}
+	current := &InventorySnapshot{
+		Version:    inventoryVersion,
+		Preset:     inventoryPreset,
+		StartedAt:  inventoryStartedAt,
+		FinishedAt: time.Now(),
+		Items:      scannedItems,
+	}
+
+	previous, err := LoadInventorySnapshot(inventoryPath)
+	if err != nil {
+		log.Warn().Err(err).Str("path", inventoryPath).Msg("<EssenceFilter> inventory: ignore previous snapshot")
+	}
+	if previous == nil {
+		LogMXUSimpleHTML(ctx, fmt.Sprintf("已记录 %d 个基质，下次运行时将与本次对比", len(scannedItems)))
+	} else {
+		logInventoryDiff(ctx, previous, DiffInventory(previous.Items, current.Items))
+	}
+
+	if err := SaveInventorySnapshot(inventoryPath, current); err != nil {
+		log.Error().Err(err).Str("path", inventoryPath).Msg("<EssenceFilter> inventory: save failed")
+		return
+	}
+	log.Info().Int("items", len(scannedItems)).Str("path", inventoryPath).Msg("<EssenceFilter> inventory saved")
+}
+
+// logInventoryDiff - 输出库存差异：日志中完整列出，MXU 中展示数量及部分条目
+func logInventoryDiff(ctx *maa.Context, previous *InventorySnapshot, diff InventoryDiff) {
+	for _, item := range diff.New {
+		log.Info().Strs("skills", item.OCRSkills).Int("row", item.Row).Int("col", item.Col).Msg("<EssenceFilter> inventory: new")
+	}
+	for _, item := range diff.Gone {
+		log.Info().Strs("skills", item.OCRSkills).Int("row", item.Row).Int("col", item.Col).Msg("<EssenceFilter> inventory: gone")
+	}
+	for _, c := range diff.LockChanged {
+		log.Info().Strs("skills", c.Current.OCRSkills).Int("row", c.Current.Row).Int("col", c.Current.Col).
+			Bool("was_locked", c.Previous.Locked).Bool("locked", c.Current.LockedAtScan).
+			Msg("<EssenceFilter> inventory: lock changed")
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(
+		`<div style="color: #00bfff; font-weight: 900; margin-top: 4px;">与上次扫描（%s）相比：新增 %d，消失 %d，锁定状态变化 %d</div>`,
+		previous.FinishedAt.Local().Format("2006-01-02 15:04"), len(diff.New), len(diff.Gone), len(diff.LockChanged),
+	))
+	writeItems := func(title string, items []InventoryItem, note func(InventoryItem) string) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString(fmt.Sprintf(`<div style="font-weight: 700;">%s：</div>`, title))
+		for i, item := range items {
+			if i == inventoryDiffShowMax {
+				b.WriteString(fmt.Sprintf(`<div style="font-size: 12px;">……等 %d 个，详见日志</div>`, len(items)))
+				break
+			}
+			b.WriteString(fmt.Sprintf(
+				`<div style="font-size: 12px;">%s %s</div>`,
+				escapeHTML(strings.Join(item.OCRSkills, " | ")), note(item),
+			))
+		}
+	}
+	position := func(item InventoryItem) string {
+		return "（第 " + strconv.Itoa(item.Row) + " 行第 " + strconv.Itoa(item.Col) + " 个）"
+	}
+	writeItems("新增", diff.New, position)
+	writeItems("消失", diff.Gone, position)
+	changed := make([]InventoryItem, len(diff.LockChanged))
+	for i, c := range diff.LockChanged {
+		changed[i] = c.Current
+	}
+	writeItems("锁定状态变化", changed, func(item InventoryItem) string {
+		if item.LockedAtScan {
+			return position(item) + "：未锁定 → 已锁定"
+		}
+		return position(item) + "：已锁定 → 未锁定"
+	})
+	LogMXUHTML(ctx, b.String())
+}
@@ -0,0 +1,121 @@
+package essencefilter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffInventory(t *testing.T) {
+	item := func(row, col int, ids []int, locked bool) InventoryItem {
+		return InventoryItem{OCRSkills: []string{"a", "b", "c"}, SkillIDs: ids, Row: row, Col: col, LockedAtScan: locked, Locked: locked}
+	}
+	pos := func(items []InventoryItem) [][2]int {
+		var p [][2]int
+		for _, it := range items {
+			p = append(p, [2]int{it.Row, it.Col})
+		}
+		return p
+	}
+
+	for _, tc := range []struct {
+		name        string
+		previous    []InventoryItem
+		current     []InventoryItem
+		wantNew     [][2]int
+		wantGone    [][2]int
+		wantChanged [][2]int // 本次扫描的位置
+	}{
+		{
+			name:     "unchanged",
+			previous: []InventoryItem{item(1, 1, []int{1, 2, 3}, true), item(1, 2, []int{4, 5, 6}, false)},
+			current:  []InventoryItem{item(1, 1, []int{1, 2, 3}, true), item(1, 2, []int{4, 5, 6}, false)},
+		},
+		{
+			name:     "new and gone",
+			previous: []InventoryItem{item(1, 1, []int{1, 2, 3}, false)},
+			current:  []InventoryItem{item(1, 1, []int{4, 5, 6}, false)},
+			wantNew:  [][2]int{{1, 1}},
+			wantGone: [][2]int{{1, 1}},
+		},
+		{
+			// 位置相同的优先配对，所以只有第 2 个基质的锁定状态变化
+			name:        "duplicates pair by position",
+			previous:    []InventoryItem{item(1, 1, []int{1, 2, 3}, true), item(1, 2, []int{1, 2, 3}, false)},
+			current:     []InventoryItem{item(1, 2, []int{1, 2, 3}, true), item(1, 1, []int{1, 2, 3}, true)},
+			wantChanged: [][2]int{{1, 2}},
+		},
+		{
+			name:        "duplicates moved",
+			previous:    []InventoryItem{item(1, 1, []int{1, 2, 3}, true), item(1, 2, []int{1, 2, 3}, true), item(1, 3, []int{1, 2, 3}, true)},
+			current:     []InventoryItem{item(2, 5, []int{1, 2, 3}, true), item(2, 6, []int{1, 2, 3}, false)},
+			wantGone:    [][2]int{{1, 3}},
+			wantChanged: [][2]int{{2, 6}},
+		},
+		{
+			name:     "unknown lock state",
+			previous: []InventoryItem{item(1, 1, []int{1, 2, 3}, true), {SkillIDs: []int{4, 5, 6}, Row: 1, Col: 2, Locked: false, LockUnknown: true}},
+			current:  []InventoryItem{{SkillIDs: []int{1, 2, 3}, Row: 1, Col: 1, LockUnknown: true}, item(1, 2, []int{4, 5, 6}, true)},
+		},
+		{
+			name:     "unrecognized slots pair by ocr text",
+			previous: []InventoryItem{item(1, 1, []int{1, 0, 3}, false)},
+			current:  []InventoryItem{item(1, 1, []int{1, 2, 3}, false)},
+			wantNew:  [][2]int{{1, 1}},
+			wantGone: [][2]int{{1, 1}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := DiffInventory(tc.previous, tc.current)
+			if got := pos(diff.New); !reflect.DeepEqual(got, tc.wantNew) {
+				t.Errorf("new %v, want %v", got, tc.wantNew)
+			}
+			if got := pos(diff.Gone); !reflect.DeepEqual(got, tc.wantGone) {
+				t.Errorf("gone %v, want %v", got, tc.wantGone)
+			}
+			var changed [][2]int
+			for _, c := range diff.LockChanged {
+				changed = append(changed, [2]int{c.Current.Row, c.Current.Col})
+				if c.Previous.Locked == c.Current.LockedAtScan {
+					t.Errorf("lock change %+v keeps the lock state", c)
+				}
+			}
+			if !reflect.DeepEqual(changed, tc.wantChanged) {
+				t.Errorf("lock changed %v, want %v", changed, tc.wantChanged)
+			}
+		})
+	}
+}
+
+func TestLoadInventorySnapshot(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name        string
+		data        string
+		wantErr     bool
+		wantMatched bool
+	}{
+		{"version 1 without matched", `{"version": 1, "items": [{"skill_ids": [1, 2, 3], "locked": true}]}`, false, false},
+		{"current version", `{"version": 2, "items": [{"skill_ids": [1, 2, 3], "matched": true, "locked": true}]}`, false, true},
+		{"newer version", `{"version": 3, "items": []}`, true, false},
+		{"no version", `{"items": []}`, true, false},
+	} {
+		path := filepath.Join(dir, tc.name+".json")
+		if err := os.WriteFile(path, []byte(tc.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err := LoadInventorySnapshot(path)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: error %v, want error %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if err == nil && (len(snapshot.Items) != 1 || snapshot.Items[0].Matched != tc.wantMatched || !snapshot.Items[0].Locked) {
+			t.Errorf("%s: items %+v", tc.name, snapshot.Items)
+		}
+	}
+
+	if snapshot, err := LoadInventorySnapshot(filepath.Join(dir, "missing.json")); snapshot != nil || err != nil {
+		t.Errorf("missing file: %+v, %v", snapshot, err)
+	}
+}
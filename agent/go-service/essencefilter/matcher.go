@@ -1,6 +1,7 @@
 package essencefilter
 
 import (
+	"slices"
 	"strings"
 	"sync"
 	"unicode"
@@ -14,48 +15,73 @@ var buildSlotIndicesOnce sync.Once
 
 // MatchEssenceSkills - 先用原始清洗文本匹配，失败后再用相近字替换后的文本匹配
 // 返回结构化的技能组合匹配结果（可能对应多把武器），不再在此处拼接武器名字符串。
+// 按当前预设的 match_mode 选择精确匹配或评分匹配。
 func MatchEssenceSkills(ctx *maa.Context, ocrSkills []string) (*SkillCombinationMatch, bool) {
 	if len(ocrSkills) != 3 {
 		log.Warn().Int("len", len(ocrSkills)).Strs("ocr_skills", ocrSkills).Msg("[EssenceFilter] MatchEssenceSkills: OCR 数量不足")
 		return nil, false
 	}
+	return matchResolvedSkills(ocrSkills, resolveOCRSkillIDs(ocrSkills))
+}
 
+// resolveOCRSkillIDs - 将三个槽位的 OCR 文本映射为技能 ID，未匹配到的槽位为 0
+func resolveOCRSkillIDs(ocrSkills []string) []int {
 	buildSlotIndicesOnce.Do(buildSlotIndices)
 
-	ocrSkillIDs := make([]int, 3)
+	ocrSkillIDs := make([]int, len(ocrSkills))
 	for i, skill := range ocrSkills {
 		id, ok := matchSkillIDEnhanced(i+1, skill)
 		if !ok {
 			log.Info().Int("slot", i+1).Str("skill", skill).Msg("[EssenceFilter] MatchEssenceSkills: OCR 未匹配到技能 ID")
-			return nil, false
+			continue
 		}
 		ocrSkillIDs[i] = id
 		log.Debug().Int("slot", i+1).Str("skill", skill).Int("skill_id", id).Msg("[EssenceFilter] OCR 技能映射结果")
 	}
+	return ocrSkillIDs
+}
+
+// matchResolvedSkills - 用 resolveOCRSkillIDs 的结果匹配目标技能组合
+func matchResolvedSkills(ocrSkills []string, ocrSkillIDs []int) (*SkillCombinationMatch, bool) {
+	if len(ocrSkillIDs) != 3 {
+		return nil, false
+	}
+	scored := targetFilter.MatchMode == MatchModeScore
+	// 精确模式下任一槽位未识别即不可能匹配；评分模式下该槽位记为 0，不命中任何组合，其余槽位仍可得分
+	if !scored && slices.Contains(ocrSkillIDs, 0) {
+		return nil, false
+	}
+
+	if scored {
+		return matchEssenceSkillsScored(ocrSkills, ocrSkillIDs)
+	}
 
 	var matchedWeapons []WeaponData
 	var skillIDs []int
 	var skillsChinese []string
+	ruleMatched := false
 	for _, combination := range targetSkillCombinations {
-		if len(combination.SkillIDs) == 3 &&
-			ocrSkillIDs[0] == combination.SkillIDs[0] &&
-			ocrSkillIDs[1] == combination.SkillIDs[1] &&
-			ocrSkillIDs[2] == combination.SkillIDs[2] {
-			if len(matchedWeapons) == 0 {
-				// 保存基础的技能 ID / 中文名信息
-				skillIDs = append([]int(nil), combination.SkillIDs...)
-				skillsChinese = append([]string(nil), combination.SkillsChinese...)
-			}
-			matchedWeapons = append(matchedWeapons, combination.Weapon)
+		if !combination.Matches(ocrSkillIDs) {
+			continue
 		}
-	}
-
-	if len(matchedWeapons) > 0 {
-		weaponNames := make([]string, 0, len(matchedWeapons))
-		for _, w := range matchedWeapons {
-			weaponNames = append(weaponNames, w.ChineseName)
+		if combination.Rule != nil {
+			ruleMatched = true
+			continue
 		}
+		if len(matchedWeapons) == 0 {
+			// 保存基础的技能 ID / 中文名信息
+			skillIDs = append([]int(nil), combination.SkillIDs...)
+			skillsChinese = append([]string(nil), combination.SkillsChinese...)
+		}
+		matchedWeapons = append(matchedWeapons, combination.Weapon)
+	}
+	// 仅命中技能规则时，没有对应武器，技能信息取自 OCR 映射结果
+	if len(matchedWeapons) == 0 && ruleMatched {
+		skillIDs = append([]int(nil), ocrSkillIDs...)
+		skillsChinese = ocrSkillNames(ocrSkillIDs)
+	}
 
+	if len(matchedWeapons) > 0 || ruleMatched {
 		result := &SkillCombinationMatch{
 			SkillIDs:      skillIDs,
 			SkillsChinese: skillsChinese,
@@ -63,11 +89,12 @@ func MatchEssenceSkills(ctx *maa.Context, ocrSkills []string) (*SkillCombination
 		}
 
 		log.Info().
-			Strs("weapons", weaponNames).
+			Strs("weapons", weaponNamesOf(matchedWeapons)).
 			Ints("ocr_skill_ids", ocrSkillIDs).
 			Ints("expected_ids", result.SkillIDs).
 			Strs("ocr_skills", ocrSkills).
 			Strs("expected_skills", result.SkillsChinese).
+			Bool("rule_matched", ruleMatched).
 			Msg("[EssenceFilter] MatchEssenceSkills: ID 匹配成功")
 		return result, true
 	}
@@ -81,6 +108,106 @@ func MatchEssenceSkills(ctx *maa.Context, ocrSkills []string) (*SkillCombination
 	return nil, false
 }
 
+// matchEssenceSkillsScored - 评分匹配：每个命中的槽位累加其权重，得分达到阈值的组合视为匹配，
+// 取其中的最高得分；得分最高的武器组合都记入结果。规则未限定的槽位不计分，
+// 阈值不超过该组合限定槽位的权重之和（见 SkillCombination.MaxScore）
+func matchEssenceSkillsScored(ocrSkills []string, ocrSkillIDs []int) (*SkillCombinationMatch, bool) {
+	weights, threshold := scoreSettings(targetFilter)
+
+	bestScore := -1.0
+	topScore := 0.0 // 所有组合中的最高得分，仅用于未匹配时的日志
+	var bestSlots []bool
+	var matchedWeapons []WeaponData
+	for _, combination := range targetSkillCombinations {
+		slots := make([]bool, 3)
+		score := 0.0
+		for i, id := range ocrSkillIDs {
+			if id != 0 && combination.SlotMatches(i+1, id) {
+				slots[i] = true
+				score += weights[i]
+			}
+		}
+		if score > topScore {
+			topScore = score
+		}
+		comboThreshold := threshold
+		if maxScore := combination.MaxScore(weights); maxScore < comboThreshold {
+			comboThreshold = maxScore
+		}
+		if score <= 0 || score < comboThreshold {
+			continue
+		}
+		switch {
+		case score > bestScore:
+			bestScore, bestSlots = score, slots
+			matchedWeapons = matchedWeapons[:0]
+		case score < bestScore:
+			continue
+		}
+		if combination.Rule == nil {
+			matchedWeapons = append(matchedWeapons, combination.Weapon)
+		}
+	}
+
+	if bestScore < 0 {
+		log.Info().
+			Ints("ocr_skill_ids", ocrSkillIDs).
+			Strs("ocr_skills", ocrSkills).
+			Float64("best_score", topScore).
+			Float64("threshold", threshold).
+			Int("target_combo_total", len(targetSkillCombinations)).
+			Msg("[EssenceFilter] MatchEssenceSkills: 得分未达到阈值")
+		return nil, false
+	}
+
+	result := &SkillCombinationMatch{
+		SkillIDs:      append([]int(nil), ocrSkillIDs...),
+		SkillsChinese: ocrSkillNames(ocrSkillIDs),
+		Weapons:       append([]WeaponData(nil), matchedWeapons...),
+		Score:         bestScore,
+		MatchedSlots:  bestSlots,
+	}
+	log.Info().
+		Strs("weapons", weaponNamesOf(result.Weapons)).
+		Ints("ocr_skill_ids", ocrSkillIDs).
+		Strs("ocr_skills", ocrSkills).
+		Float64("score", bestScore).
+		Float64("threshold", threshold).
+		Msg("[EssenceFilter] MatchEssenceSkills: 评分匹配成功")
+	return result, true
+}
+
+// scoreSettings - 评分模式的槽位权重与阈值；权重未配置时每槽位为 1，阈值未配置时为三槽位权重之和（即全部命中）。
+// 配置须已通过 ValidateFilterConfig
+func scoreSettings(config FilterConfig) ([3]float64, float64) {
+	weights := [3]float64{1, 1, 1}
+	if len(config.SlotWeights) > 0 {
+		copy(weights[:], config.SlotWeights)
+	}
+	threshold := config.ScoreThreshold
+	if threshold <= 0 {
+		threshold = weights[0] + weights[1] + weights[2]
+	}
+	return weights, threshold
+}
+
+// ocrSkillNames - OCR 映射得到的技能 ID 对应的技能中文名，未识别的槽位为空
+func ocrSkillNames(ids []int) []string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = skillNameByID(id, getPoolBySlot(i+1))
+	}
+	return names
+}
+
+func weaponNamesOf(weapons []WeaponData) []string {
+	names := make([]string, 0, len(weapons))
+	for _, w := range weapons {
+		names = append(names, w.ChineseName)
+	}
+	return names
+}
+
 // 预处理后的技能条目
 type skillEntry struct {
 	ID            int
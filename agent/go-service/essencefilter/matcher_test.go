@@ -0,0 +1,80 @@
+package essencefilter
+
+import (
+	"reflect"
+	"testing"
+)
+
+// setTargets - 替换本次运行的目标组合与过滤配置，测试结束后恢复
+func setTargets(t *testing.T, combos []SkillCombination, filter FilterConfig) {
+	t.Helper()
+	oldCombos, oldFilter := targetSkillCombinations, targetFilter
+	t.Cleanup(func() { targetSkillCombinations, targetFilter = oldCombos, oldFilter })
+	targetSkillCombinations, targetFilter = combos, filter
+}
+
+func TestMatchResolvedSkills(t *testing.T) {
+	weapons := []WeaponData{
+		{InternalID: "a", ChineseName: "甲", SkillIDs: []int{1, 10, 20}},
+		{InternalID: "b", ChineseName: "乙", SkillIDs: []int{1, 10, 20}},
+		{InternalID: "c", ChineseName: "丙", SkillIDs: []int{2, 11, 21}},
+	}
+	rules := []SkillRule{{Slot1: []int{2}, Slot3: []int{20}}}
+	ocr := []string{"x", "y", "z"}
+
+	for _, tc := range []struct {
+		name        string
+		filter      FilterConfig
+		ids         []int
+		wantMatched bool
+		wantWeapons []string
+		wantScore   float64
+		wantSlots   []bool
+	}{
+		{"exact: weapon", FilterConfig{}, []int{1, 10, 20}, true, []string{"a", "b"}, 0, nil},
+		{"exact: rule only", FilterConfig{}, []int{2, 99, 20}, true, nil, 0, nil},
+		{"exact: two of three", FilterConfig{}, []int{1, 10, 21}, false, nil, 0, nil},
+		{"exact: unrecognized slot", FilterConfig{}, []int{1, 10, 0}, false, nil, 0, nil},
+		{"exact: too few skills", FilterConfig{}, []int{1, 10}, false, nil, 0, nil},
+
+		{"score: all slots", FilterConfig{MatchMode: MatchModeScore}, []int{1, 10, 20}, true, []string{"a", "b"}, 3, []bool{true, true, true}},
+		{"score: below default threshold", FilterConfig{MatchMode: MatchModeScore}, []int{1, 10, 99}, false, nil, 0, nil},
+		{"score: two of three", FilterConfig{MatchMode: MatchModeScore, ScoreThreshold: 2}, []int{1, 99, 20}, true, []string{"a", "b"}, 2, []bool{true, false, true}},
+		{"score: unrecognized slot", FilterConfig{MatchMode: MatchModeScore, ScoreThreshold: 2}, []int{0, 11, 21}, true, []string{"c"}, 2, []bool{false, true, true}},
+		{"score: weighted", FilterConfig{MatchMode: MatchModeScore, SlotWeights: []float64{3, 1, 1}, ScoreThreshold: 3}, []int{2, 99, 99}, true, []string{"c"}, 3, []bool{true, false, false}},
+		{"score: rule constrained slots only", FilterConfig{MatchMode: MatchModeScore}, []int{2, 99, 20}, true, nil, 2, []bool{true, false, true}},
+		{"score: rule half hit", FilterConfig{MatchMode: MatchModeScore}, []int{2, 99, 99}, false, nil, 0, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setTargets(t, ExtractSkillCombinations(weapons, rules), tc.filter)
+			result, matched := matchResolvedSkills(ocr, tc.ids)
+			if matched != tc.wantMatched {
+				t.Fatalf("matched %v, want %v", matched, tc.wantMatched)
+			}
+			if !matched {
+				return
+			}
+			var ids []string
+			for _, w := range result.Weapons {
+				ids = append(ids, w.InternalID)
+			}
+			if !reflect.DeepEqual(ids, tc.wantWeapons) {
+				t.Errorf("weapons %v, want %v", ids, tc.wantWeapons)
+			}
+			if result.Score != tc.wantScore || !reflect.DeepEqual(result.MatchedSlots, tc.wantSlots) {
+				t.Errorf("score %v slots %v, want %v %v", result.Score, result.MatchedSlots, tc.wantScore, tc.wantSlots)
+			}
+		})
+	}
+}
+
+// 规则 {slot1:[X]} 在阈值为 2 时只按限定的词条 1 计分：词条 1 不命中时不能靠不限的槽位锁定
+func TestMatchResolvedSkillsUnconstrainedSlotsScoreNothing(t *testing.T) {
+	setTargets(t, []SkillCombination{{Rule: &SkillRule{Slot1: []int{5}}}}, FilterConfig{MatchMode: MatchModeScore, ScoreThreshold: 2})
+	if result, matched := matchResolvedSkills([]string{"x", "y", "z"}, []int{6, 7, 8}); matched {
+		t.Errorf("slot 1 missed but matched with %+v", result)
+	}
+	if _, matched := matchResolvedSkills([]string{"x", "y", "z"}, []int{5, 7, 8}); !matched {
+		t.Error("slot 1 hit but not matched")
+	}
+}
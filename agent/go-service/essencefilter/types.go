@@ -1,5 +1,7 @@
 package essencefilter
 
+import "time"
+
 // WeaponData - weapon data
 type WeaponData struct {
 	InternalID    string   `json:"internal_id"`
@@ -41,16 +43,40 @@ type FilterPreset struct {
 
 // FilterConfig - filtering config
 type FilterConfig struct {
-	TypeIDs   []int `json:"type_ids"`   // optional weapon type filter
-	MinRarity int   `json:"min_rarity"` // min rarity
-	MaxRarity int   `json:"max_rarity"` // max rarity
+	TypeIDs          []int       `json:"type_ids"`           // optional weapon type filter
+	MinRarity        int         `json:"min_rarity"`         // min rarity
+	MaxRarity        int         `json:"max_rarity"`         // max rarity
+	WeaponIDs        []string    `json:"weapon_ids"`         // optional: only these weapons (internal_id)
+	ExcludeWeaponIDs []string    `json:"exclude_weapon_ids"` // optional: never these weapons (internal_id)
+	SkillRules       []SkillRule `json:"skill_rules"`        // optional: essences satisfying any rule are targets, see SkillRule
+
+	// 匹配方式：MatchModeExact（默认）要求三个词条都命中同一目标组合；
+	// MatchModeScore 按槽位权重累计命中词条的得分，对任一目标组合的最高得分达到阈值即锁定
+	MatchMode      string    `json:"match_mode"`
+	SlotWeights    []float64 `json:"slot_weights"`    // score mode: weight of slot 1~3, default 1 each
+	ScoreThreshold float64   `json:"score_threshold"` // score mode: min score to lock, default all slots
 }
 
-// SkillCombination - target skill combination（静态配置，一把武器一条）
+// Match modes of FilterConfig
+const (
+	MatchModeExact = "exact"
+	MatchModeScore = "score"
+)
+
+// SkillRule - 按槽位直接给出允许的技能 ID，某槽位为空表示该槽位任意技能均可
+// 例如 {"slot1": [3], "slot3": [1, 2]}：词条1为主能力提升，词条3为强攻或残暴，词条2不限
+type SkillRule struct {
+	Slot1 []int `json:"slot1"`
+	Slot2 []int `json:"slot2"`
+	Slot3 []int `json:"slot3"`
+}
+
+// SkillCombination - target skill combination（静态配置，一把武器一条；或一条技能规则）
 type SkillCombination struct {
 	Weapon        WeaponData
-	SkillsChinese []string // [slot1_cn, slot2_cn, slot3_cn]
-	SkillIDs      []int    // [slot1_id, slot2_id, slot3_id]
+	SkillsChinese []string   // [slot1_cn, slot2_cn, slot3_cn]
+	SkillIDs      []int      // [slot1_id, slot2_id, slot3_id]
+	Rule          *SkillRule // 非空时为技能规则组合，此时 Weapon / SkillIDs 为空
 }
 
 // SkillCombinationMatch - 运行时匹配结果：同一套技能可能对应多把武器
@@ -58,6 +84,10 @@ type SkillCombinationMatch struct {
 	SkillIDs      []int
 	SkillsChinese []string
 	Weapons       []WeaponData
+
+	// 仅评分模式：最高得分及其命中的槽位（slot 1~3 对应下标 0~2）
+	Score        float64
+	MatchedSlots []bool
 }
 
 // SkillCombinationSummary - 本次运行中某一套技能组合的锁定统计
@@ -67,6 +97,8 @@ type SkillCombinationSummary struct {
 	OCRSkills     []string // 实际本次匹配时 OCR 到的技能文本（用于展示）
 	Weapons       []WeaponData
 	Count         int
+	Score         float64 // 评分模式下的得分
+	MatchedSlots  []bool  // 评分模式下命中的槽位，精确模式为空
 }
 
 // MatcherConfig - 匹配器配置结构
@@ -79,11 +111,23 @@ type MatcherConfig struct {
 var (
 	weaponDB                WeaponDatabase
 	targetSkillCombinations []SkillCombination
+	targetFilter            FilterConfig // 当前预设的过滤配置，决定匹配方式
 	visitedCount            int
 	matchedCount            int
 	filteredSkillStats      [3]map[int]int
 	statsLogged             bool
 
+	// 本次运行中按 discard_mode 处理（或预览）的未匹配基质数量
+	discardedCount     int
+	discardLimitLogged bool
+	discardSummary     DiscardConfig // 最近一次使用的处理方式，用于结束时的摘要
+
+	// 本次运行扫描到的基质，结束时写入库存记录（见 inventory.go）
+	scannedItems       []InventoryItem
+	currentItemPos     [2]int // 当前格子的 [row, col]
+	inventoryPreset    string
+	inventoryStartedAt time.Time
+
 	// 本次运行中命中的技能组合摘要，按技能 ID 组合聚合
 	matchedCombinationSummary map[string]*SkillCombinationSummary
 
@@ -9,6 +9,11 @@ import (
 )
 
 func main() {
+	// Offline subcommands (e.g. "puzzle solve") run without the MAA framework
+	if ok, code := runOfflineCommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
 	logFile, err := initLogger()
 	if err != nil {
 		log.Fatal().
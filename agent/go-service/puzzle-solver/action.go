@@ -1,7 +1,15 @@
 package puzzle
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/MaaXYZ/maa-framework-go/v4"
@@ -10,6 +18,9 @@ import (
 
 type Action struct{}
 
+// defaultSolveTimeoutMs bounds the solver when the action param gives no "timeoutMs"
+const defaultSolveTimeoutMs = 30000
+
 // doPlace performs the interaction to place a single puzzle piece
 func doPlace(ctx *maa.Context, bd *BoardDesc, p Placement, isDryRun bool) {
 	log.Debug().
@@ -19,30 +30,16 @@ func doPlace(ctx *maa.Context, bd *BoardDesc, p Placement, isDryRun bool) {
 		Int("Rotation", p.Rotation).
 		Msg("Placing puzzle piece")
 
-	// 1. Recalculate thumbnail location
-	// We assume thumbnails are analyzed in standard grid order (row by row, col by col)
-	row := p.PuzzleIndex / int(PUZZLE_THUMB_MAX_COLS)
-	col := p.PuzzleIndex % int(PUZZLE_THUMB_MAX_COLS)
-	thumbX := PUZZLE_THUMB_START_X + float64(col)*PUZZLE_THUMB_W
-	thumbY := PUZZLE_THUMB_START_Y + float64(row)*PUZZLE_THUMB_H
-
-	startX := int(thumbX + PUZZLE_THUMB_W/2)
-	startY := int(thumbY + PUZZLE_THUMB_H/2)
+	// 1. Recalculate thumbnail location, the panel is expected to show its page already
+	_, slot := bd.getThumbSlot(p.PuzzleIndex)
+	startX, startY := getThumbCenter(slot)
 
 	// 2. Calculate target location on board
 	if bd.W <= 0 || bd.H <= 0 {
 		log.Error().Msg("Invalid BoardDesc: missing W/H dimensions")
 		return
 	}
-	maxW, maxH := bd.W, bd.H
-
-	// targetX = CENTER_BLOCK_LT_X + (MachineX - (maxW-1)/2) * BLOCK_W + BLOCK_W/2
-	ltX, ltY := convertBoardCoordToLTCoord(p.MachineX, p.MachineY, maxW, maxH)
-	targetX := float64(ltX) + BOARD_BLOCK_W/2
-	targetY := float64(ltY) + BOARD_BLOCK_H/2
-
-	endX := int(targetX)
-	endY := int(targetY)
+	endX, endY := getBlockCenter(p.MachineX, p.MachineY, bd.W, bd.H)
 
 	// 3. Execution sequence
 	aw := NewActionWrapper(ctx.GetTasker().GetController())
@@ -51,9 +48,7 @@ func doPlace(ctx *maa.Context, bd *BoardDesc, p Placement, isDryRun bool) {
 	aw.TouchMoveSync(0, endX, endY, 250)
 
 	// 4. Rotation
-	// Mapping: 0->0, 1->3, 2->2, 3->1
-	rotTimes := (4 - p.Rotation) % 4
-	for range rotTimes {
+	for range getRotationPresses(p.Rotation) {
 		aw.TypeKeySync(82, 250) // R key
 	}
 
@@ -67,6 +62,195 @@ func doPlace(ctx *maa.Context, bd *BoardDesc, p Placement, isDryRun bool) {
 	aw.TouchUpSync(100)
 }
 
+// getPlacementMisses compares the board before and after placing a puzzle.
+// It returns the target blocks not showing the puzzle's hue,
+// and the blocks newly showing its hue, i.e. where the puzzle actually landed.
+func getPlacementMisses(before, after image.Image, bd *BoardDesc, pz *Puzzle, p Placement) ([][2]int, [][2]int) {
+	hue := bd.HueList[pz.Color]
+	hasHue := func(img image.Image, x, y int) bool {
+		h, filled := getBoardBlockColor(img, x, y, bd.W, bd.H)
+		return filled && diffHue(h, hue) <= PUZZLE_HUE_DIFF_GRT
+	}
+
+	var missing, landed [][2]int
+	for _, c := range getFootprint(pz, p) {
+		if !hasHue(after, c[0], c[1]) {
+			missing = append(missing, c)
+		}
+	}
+	for y := range bd.H {
+		for x := range bd.W {
+			if hasHue(after, x, y) && !hasHue(before, x, y) {
+				landed = append(landed, [2]int{x, y})
+			}
+		}
+	}
+	return missing, landed
+}
+
+// doPickUp drags the puzzle covering the board block back to its thumbnail
+func doPickUp(ctx *maa.Context, bd *BoardDesc, block [2]int, puzzleIndex int) {
+	startX, startY := getBlockCenter(block[0], block[1], bd.W, bd.H)
+	_, slot := bd.getThumbSlot(puzzleIndex)
+	endX, endY := getThumbCenter(slot)
+
+	aw := NewActionWrapper(ctx.GetTasker().GetController())
+	aw.TouchUpSync(100)
+	aw.TouchDownSync(0, startX, startY, 100)
+	aw.TouchMoveSync(0, endX, endY, 250)
+	aw.TouchUpSync(100)
+}
+
+// doPlaceVerified places a puzzle piece, then checks on a fresh screenshot that it covers its target blocks.
+// A piece that landed elsewhere is picked up, and placing is retried.
+func doPlaceVerified(ctx *maa.Context, bd *BoardDesc, pz *Puzzle, p Placement) bool {
+	before := doCaptureImage(ctx)
+	if before == nil {
+		return false
+	}
+
+	for attempt := 1; attempt <= PUZZLE_PLACE_MAX_ATTEMPTS; attempt++ {
+		doPlace(ctx, bd, p, false)
+		time.Sleep(250 * time.Millisecond)
+
+		after := doCaptureImage(ctx)
+		if after == nil {
+			return false
+		}
+		missing, landed := getPlacementMisses(before, after, bd, pz, p)
+		if len(missing) == 0 {
+			return true
+		}
+
+		log.Warn().
+			Int("PuzzleIndex", p.PuzzleIndex).
+			Int("attempt", attempt).
+			Interface("missing", missing).
+			Interface("landed", landed).
+			Msg("Puzzle piece did not land on its target")
+		if len(landed) > 0 {
+			doPickUp(ctx, bd, landed[0], p.PuzzleIndex)
+			time.Sleep(250 * time.Millisecond)
+		}
+	}
+	return false
+}
+
+// doWaitComplete waits for the puzzle completion tip to show up
+func doWaitComplete(ctx *maa.Context) bool {
+	deadline := time.Now().Add(time.Duration(PUZZLE_COMPLETE_WAIT_MS) * time.Millisecond)
+	for {
+		if img := doCaptureImage(ctx); img != nil {
+			res, err := ctx.RunRecognition("PuzzleSolverFindCompleteTip", img)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to recognize puzzle completion tip")
+			} else if res != nil && res.Hit {
+				return true
+			}
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// solutionImageFile is the annotated screenshot of the latest solution of each board tab, under debug/
+const solutionImageFile = "puzzle_solution_tab%d.png"
+
+// doShowSolution shows the solution grid in MXU, and saves the board screenshot the solution was found on,
+// annotated with the solution, under debug/ and into the recorded session if any.
+func doShowSolution(ctx *maa.Context, bd *BoardDesc, placements []Placement) {
+	if html, err := RenderBoardHTML(bd, placements); err != nil {
+		log.Error().Err(err).Msg("Failed to render puzzle solution")
+	} else {
+		showMessage(ctx, "🧩 拼图解法预览：<br/>"+html)
+	}
+
+	img := getBoardScreen(bd.Tab)
+	if img == nil {
+		return
+	}
+	annotated, err := RenderSolutionImage(img, bd, placements)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render puzzle solution image")
+		return
+	}
+	path := filepath.Join(".", "debug", fmt.Sprintf(solutionImageFile, bd.Tab))
+	if err := savePNG(path, annotated); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to save puzzle solution image")
+	} else {
+		log.Info().Str("path", path).Msg("Saved puzzle solution image")
+	}
+	recordSolutionImage(bd.Tab, annotated)
+}
+
+// savePNG writes the image to path, creating its directory if needed
+func savePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// newSolveContext returns a context that is cancelled when the tasker is stopping
+// or, if timeoutMs is positive, when the timeout expires.
+func newSolveContext(ctx *maa.Context, timeoutMs int) (context.Context, context.CancelFunc) {
+	var solveCtx context.Context
+	var cancel context.CancelFunc
+	if timeoutMs > 0 {
+		solveCtx, cancel = context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
+	} else {
+		solveCtx, cancel = context.WithCancel(context.Background())
+	}
+
+	tasker := ctx.GetTasker()
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-solveCtx.Done():
+				return
+			case <-ticker.C:
+				if tasker.Stopping() {
+					log.Info().Msg("Tasker is stopping, cancelling puzzle solver")
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return solveCtx, cancel
+}
+
+// doReportUnsolvable logs and shows why the board has no solution
+func doReportUnsolvable(ctx *maa.Context, bd *BoardDesc) {
+	diagnoses, err := Diagnose(bd)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to diagnose unsolvable puzzle")
+		return
+	}
+
+	reasons := make([]string, 0, len(diagnoses))
+	for _, d := range diagnoses {
+		reasons = append(reasons, d.Message)
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "no single constraint fails, the puzzles cannot satisfy all projections together")
+	}
+	log.Warn().Strs("reasons", reasons).Msg("Puzzle is unsolvable")
+	showMessage(ctx, "❌ 拼图无解，可能是识别有误：\n- "+strings.Join(reasons, "\n- "))
+}
+
 func doResetCursor(ctx *maa.Context) {
 	aw := NewActionWrapper(ctx.GetTasker().GetController())
 	aw.TouchUpSync(100)
@@ -74,24 +258,74 @@ func doResetCursor(ctx *maa.Context) {
 	aw.TouchUpSync(100)
 }
 
-// Run executes the puzzle solving action.
+// actionConfig holds the custom action parameters
+type actionConfig struct {
+	isDryRun      bool
+	timeoutMs     int
+	minConfidence float64
+	useCache      bool // Reuse the placements cached for the same board, see BoardFingerprint
+	opts          SolveOptions
+}
+
+// tabResult is the outcome of one board tab, reported at the end of the action
+type tabResult struct {
+	tab    int
+	placed int  // Number of pieces placed
+	ok     bool // Whether the board was completed, or skipped for having no puzzles
+	empty  bool // Whether the board had no puzzles
+}
+
+// Run executes the puzzle solving action on every board tab, starting with the recognized one.
 func (a *Action) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 	log.Info().
 		Str("action", arg.CustomActionName).
 		Msg("Starting PuzzleSolver action")
 
 	// Parse custom action parameters
-	isDryRun := false
+	cfg := &actionConfig{
+		timeoutMs:     defaultSolveTimeoutMs,
+		minConfidence: PUZZLE_MIN_CONFIDENCE,
+		useCache:      true,
+	}
 	if arg.CustomActionParam != "" {
 		var params struct {
-			DryRun bool `json:"dryRun"`
+			DryRun        bool     `json:"dryRun"`
+			Engine        string   `json:"engine"`
+			TimeoutMs     *int     `json:"timeoutMs"`
+			NodeBudget    int      `json:"nodeBudget"`
+			MaxSolutions  int      `json:"maxSolutions"`
+			Workers       int      `json:"workers"`
+			MinConfidence *float64 `json:"minConfidence"`
+			Cache         *bool    `json:"cache"`
 		}
 		if err := json.Unmarshal([]byte(arg.CustomActionParam), &params); err == nil {
-			isDryRun = params.DryRun
+			cfg.isDryRun = params.DryRun
+			cfg.opts.Engine = Engine(params.Engine)
+			cfg.opts.NodeBudget = params.NodeBudget
+			cfg.opts.MaxSolutions = params.MaxSolutions
+			cfg.opts.Workers = params.Workers
+			if params.TimeoutMs != nil {
+				cfg.timeoutMs = *params.TimeoutMs
+			}
+			if params.MinConfidence != nil {
+				cfg.minConfidence = *params.MinConfidence
+			}
+			if params.Cache != nil {
+				cfg.useCache = *params.Cache
+			}
 		}
 	}
+	cfg.opts.Progress = func(p SolveProgress) {
+		log.Info().
+			Int("nodes", p.Nodes).
+			Int("depth", p.Depth).
+			Int("maxDepth", p.MaxDepth).
+			Dur("elapsed", p.Elapsed).
+			Msg("Puzzle solver progress")
+		showMessage(ctx, fmt.Sprintf("🤔 拼图求解中：已搜索 %d 个节点，最深放置 %d 块", p.Nodes, p.MaxDepth))
+	}
 
-	if isDryRun {
+	if cfg.isDryRun {
 		log.Info().Msg("Dry run mode enabled: actions will be logged but not executed")
 	}
 
@@ -102,42 +336,222 @@ func (a *Action) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
 		return false
 	}
 
-	var boardDesc BoardDesc
-	if err := json.Unmarshal([]byte(recData), &boardDesc); err != nil {
+	boardDesc, err := ParseBoardDesc([]byte(recData))
+	if err != nil {
 		log.Error().Err(err).Msg("Failed to unmarshal board state")
 		return false
 	}
 
-	// MaaFramework wrapping logic: if HueList is missing, check if it's wrapped in "best.detail"
-	if len(boardDesc.HueList) == 0 {
-		var wrapped struct {
-			Best struct {
-				Detail json.RawMessage `json:"detail"`
-			} `json:"best"`
-		}
-		if err := json.Unmarshal([]byte(recData), &wrapped); err == nil && len(wrapped.Best.Detail) > 0 {
-			if err := json.Unmarshal(wrapped.Best.Detail, &boardDesc); err != nil {
-				log.Error().Err(err).Msg("Failed to unmarshal wrapped board state")
-				return false
+	var results []tabResult
+	palette := getPalette(defaultResourceDir)
+	for tab := boardDesc.Tab; tab < len(TAB_X_LIST); tab++ {
+		if tab > boardDesc.Tab {
+			if ctx.GetTasker().Stopping() {
+				break
+			}
+			img := doCaptureImage(ctx)
+			if img == nil {
+				break
+			}
+			bd, err := doRecognizeTab(ctx, img, palette, tab, cfg.minConfidence)
+			if errors.Is(err, errNoTab) {
+				break
 			}
+			if errors.Is(err, errNoPuzzles) {
+				log.Info().Int("tab", tab).Msg("No puzzles on board tab")
+				results = append(results, tabResult{tab: tab, ok: true, empty: true})
+				continue
+			}
+			if err != nil {
+				log.Error().Err(err).Int("tab", tab).Msg("Failed to recognize puzzle board")
+				results = append(results, tabResult{tab: tab})
+				continue
+			}
+			boardDesc = bd
+			if data, err := json.Marshal(bd); err == nil {
+				recData = string(data)
+			}
+		}
+
+		log.Info().Int("tab", tab).Msg("Solving puzzle board tab")
+		placed, ok := doSolveBoard(ctx, cfg, boardDesc, recData, palette)
+		results = append(results, tabResult{tab: tab, placed: placed, ok: ok})
+	}
+	doReportTabs(ctx, results)
+	log.Info().Msg("Finished PuzzleSolver action")
+
+	for _, r := range results {
+		if !r.ok {
+			return false
+		}
+	}
+	return true
+}
+
+// doSolveBoard solves the board of one tab and places the pieces, recognizing and solving again
+// if the puzzle is not complete afterwards. It returns the number of pieces placed.
+func doSolveBoard(ctx *maa.Context, cfg *actionConfig, boardDesc *BoardDesc, recData string, palette *Palette) (int, bool) {
+	placed := 0
+	for round := 1; ; round++ {
+		// Refuse to act on an inconsistent board
+		if boardDesc.Confidence < cfg.minConfidence {
+			issues, _ := ValidateBoardDesc(boardDesc)
+			log.Error().
+				Float64("confidence", boardDesc.Confidence).
+				Float64("minConfidence", cfg.minConfidence).
+				Strs("issues", issues).
+				Str("detail", recData).
+				Msg("Puzzle board confidence too low, refusing to act")
+			showMessage(ctx, fmt.Sprintf("❌ 拼图识别结果可信度过低（%.0f%%），已放弃操作", boardDesc.Confidence*100))
+			return placed, false
+		}
+
+		placements, ok := doSolveCached(ctx, cfg, boardDesc, recData)
+		if !ok {
+			return placed, false
+		}
+		recordPlacements(boardDesc.Tab, placements)
+		doShowSolution(ctx, boardDesc, placements)
+		if !doExecute(ctx, boardDesc, placements, cfg.isDryRun) {
+			log.Error().Msg("Failed to place puzzle pieces")
+			showMessage(ctx, "❌ 拼图块多次未能放到目标位置")
+			doResetCursor(ctx)
+			return placed, false
+		}
+		placed += len(placements)
+		doResetCursor(ctx)
+		if cfg.isDryRun || doWaitComplete(ctx) {
+			return placed, true
+		}
+
+		// Some piece was displaced or misread, solve what is left on the board again
+		if round >= PUZZLE_RESOLVE_MAX_ROUNDS {
+			log.Error().Int("rounds", round).Msg("Puzzle is still not complete, giving up")
+			showMessage(ctx, "❌ 拼图放置完毕但未完成，已放弃操作")
+			return placed, false
+		}
+		log.Warn().Int("round", round).Msg("Puzzle is not complete after placing all pieces, recognizing again")
+		showMessage(ctx, "🤔 拼图放置完毕但未完成，重新识别剩余部分")
+		img := doCaptureImage(ctx)
+		if img == nil {
+			return placed, false
+		}
+		bd, err := doRecognizeTab(ctx, img, palette, boardDesc.Tab, cfg.minConfidence)
+		if errors.Is(err, errNoPuzzles) {
+			// Every piece of this tab is on the board, the completion tip may wait for the other tabs
+			log.Info().Int("tab", boardDesc.Tab).Msg("No puzzles left on board tab")
+			return placed, true
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to recognize puzzle board again")
+			return placed, false
+		}
+		boardDesc = bd
+		if data, err := json.Marshal(bd); err == nil {
+			recData = string(data)
 		}
 	}
+}
 
-	// Solve the puzzle
-	placements, err := Solve(&boardDesc)
+// doReportTabs shows the results of all board tabs together
+func doReportTabs(ctx *maa.Context, results []tabResult) {
+	lines := make([]string, 0, len(results))
+	for _, r := range results {
+		var status string
+		switch {
+		case r.empty:
+			status = "⏭️ 没有拼图块"
+		case r.ok:
+			status = fmt.Sprintf("✅ 已完成，放置 %d 块", r.placed)
+		default:
+			status = fmt.Sprintf("❌ 未完成，已放置 %d 块", r.placed)
+		}
+		lines = append(lines, fmt.Sprintf("第 %d 个棋盘：%s", r.tab+1, status))
+		log.Info().Int("tab", r.tab).Int("placed", r.placed).Bool("ok", r.ok).Bool("empty", r.empty).Msg("Puzzle board tab result")
+	}
+	showMessage(ctx, "🧩 拼图结果：<br/>"+strings.Join(lines, "<br/>"))
+}
+
+// doSolveCached reuses the placements cached for the same board if they still solve it,
+// and otherwise solves the board and caches the placements chosen
+func doSolveCached(ctx *maa.Context, cfg *actionConfig, bd *BoardDesc, recData string) ([]Placement, bool) {
+	if !cfg.useCache {
+		return doSolve(ctx, bd, cfg.opts, cfg.timeoutMs, recData)
+	}
+	fingerprint, err := BoardFingerprint(bd)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fingerprint puzzle board, solving without cache")
+		return doSolve(ctx, bd, cfg.opts, cfg.timeoutMs, recData)
+	}
+	if placements, ok := loadCachedSolution(bd, fingerprint); ok {
+		log.Info().Str("fingerprint", fingerprint).Interface("placements", placements).Msg("Using cached puzzle solution")
+		showMessage(ctx, "💾 已复用此前求得的拼图解法")
+		return placements, true
+	}
+	placements, ok := doSolve(ctx, bd, cfg.opts, cfg.timeoutMs, recData)
+	if ok {
+		saveCachedSolution(fingerprint, placements)
+	}
+	return placements, ok
+}
+
+// doSolve solves the board, logging the solutions found, and showing why if there is none
+func doSolve(ctx *maa.Context, bd *BoardDesc, opts SolveOptions, timeoutMs int, recData string) ([]Placement, bool) {
+	solveCtx, cancel := newSolveContext(ctx, timeoutMs)
+	result, err := SolveAll(solveCtx, bd, opts)
+	cancel()
 	if err != nil {
 		log.Error().Err(err).Str("detail", recData).Msg("Failed to solve puzzle")
-		return false
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			showMessage(ctx, fmt.Sprintf("⌛ 拼图求解超时（%d 毫秒）", timeoutMs))
+		case errors.Is(err, ErrNodeBudgetExceeded):
+			showMessage(ctx, fmt.Sprintf("⌛ 拼图求解超出搜索节点上限（%d）", opts.NodeBudget))
+		case errors.Is(err, ErrNoSolution):
+			doReportUnsolvable(ctx, bd)
+		}
+		return nil, false
+	}
+	placements := result.Solutions[0]
+	switch {
+	case len(result.Solutions) > 1:
+		log.Info().
+			Int("solutions", len(result.Solutions)).
+			Bool("exhaustive", result.Exhaustive).
+			Floats64("costs", result.Costs).
+			Msg("Puzzle has several solutions, choosing the cheapest one")
+	case result.Exhaustive:
+		log.Info().Float64("cost", result.Costs[0]).Msg("Puzzle has a unique solution")
+	default:
+		log.Info().Float64("cost", result.Costs[0]).Msg("Puzzle has at least one solution")
 	}
 	log.Info().Interface("placements", placements).Msg("Puzzle solved successfully")
+	return placements, true
+}
 
-	// Execute the solution steps (placements)
-	for _, p := range placements {
-		doPlace(ctx, &boardDesc, p, isDryRun)
-		time.Sleep(250 * time.Millisecond)
+// doExecute performs the placements, verifying each of them unless in dry run mode.
+// Recognition left the thumbnail panel on the first page.
+func doExecute(ctx *maa.Context, bd *BoardDesc, placements []Placement, isDryRun bool) bool {
+	_, puzzles, err := prepare(bd)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to prepare puzzles")
+		return false
 	}
-	doResetCursor(ctx)
-	log.Info().Msg("Finished PuzzleSolver action")
 
+	page := 0
+	for _, p := range placements {
+		if pg, _ := bd.getThumbSlot(p.PuzzleIndex); pg != page {
+			doScrollThumbPage(ctx, pg)
+			page = pg
+		}
+		if isDryRun {
+			doPlace(ctx, bd, p, true)
+			time.Sleep(250 * time.Millisecond)
+			continue
+		}
+		if !doPlaceVerified(ctx, bd, puzzles[p.PuzzleIndex], p) {
+			return false
+		}
+	}
 	return true
 }
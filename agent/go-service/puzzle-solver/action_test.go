@@ -0,0 +1,53 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestGetPlacementMisses(t *testing.T) {
+	bd := &BoardDesc{
+		W:          3,
+		H:          3,
+		PuzzleList: []*PuzzleDesc{{Blocks: [][2]int{{0, 0}, {1, 0}}, Hue: 216}},
+		HueList:    []int{216},
+	}
+	pz := &Puzzle{}
+	pz.convertFromPuzzleDesc(0, bd.PuzzleList[0], map[int]int{216: 0})
+	p := Placement{MachineX: 0, MachineY: 1, Rotation: 0, PuzzleIndex: 0}
+
+	drawBoard := func(cells ...[2]int) image.Image {
+		img := newFilledImage(color.RGBA{30, 30, 30, 255})
+		for _, c := range cells {
+			ltX, ltY := convertBoardCoordToLTCoord(c[0], c[1], bd.W, bd.H)
+			fillChecker(img, image.Rect(ltX, ltY, ltX+int(BOARD_BLOCK_W), ltY+int(BOARD_BLOCK_H)),
+				color.RGBA{20, 90, 200, 255}, color.RGBA{30, 100, 210, 255})
+		}
+		return img
+	}
+	before := drawBoard([2]int{2, 0}) // A locked block of the same hue
+
+	missing, landed := getPlacementMisses(before, drawBoard([2]int{2, 0}, [2]int{0, 1}, [2]int{1, 1}), bd, pz, p)
+	if len(missing) != 0 {
+		t.Errorf("placed on target: missing %v", missing)
+	}
+	if want := [][2]int{{0, 1}, {1, 1}}; !reflect.DeepEqual(landed, want) {
+		t.Errorf("placed on target: landed %v, want %v", landed, want)
+	}
+
+	missing, landed = getPlacementMisses(before, drawBoard([2]int{2, 0}, [2]int{1, 2}, [2]int{2, 2}), bd, pz, p)
+	if want := [][2]int{{0, 1}, {1, 1}}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("placed elsewhere: missing %v, want %v", missing, want)
+	}
+	if want := [][2]int{{1, 2}, {2, 2}}; !reflect.DeepEqual(landed, want) {
+		t.Errorf("placed elsewhere: landed %v, want %v", landed, want)
+	}
+
+	missing, landed = getPlacementMisses(before, before, bd, pz, p)
+	if len(missing) != 2 || len(landed) != 0 {
+		t.Errorf("dropped: missing %v, landed %v", missing, landed)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Solution cache layout: one <fingerprint>.json file per solved board, holding a cachedSolution,
+// under cache/puzzle/ of the user directory, i.e. the working directory of the agent
+const (
+	solutionCacheDir     = "puzzle"
+	solutionCacheFile    = "%s.json"
+	solutionCacheVersion = 1 // Bump when the fingerprint or the placements change meaning
+)
+
+var solutionCacheRoot = filepath.Join(".", "cache", solutionCacheDir)
+
+// cachedSolution is the placements chosen for a board with the given fingerprint
+type cachedSolution struct {
+	Version     int         `json:"version"`
+	Fingerprint string      `json:"fingerprint"`
+	SolvedAt    time.Time   `json:"solvedAt"`
+	Placements  []Placement `json:"placements"`
+}
+
+// BoardFingerprint returns a content hash of what the solver sees of the board: its size, projections,
+// banned and locked cells by hue index, and the shape and hue index of each puzzle in order.
+// Screen locations, exact hues, the tab, thumbnail slots and the confidence do not count,
+// so that the same layout recognized again gets the same fingerprint.
+func BoardFingerprint(bd *BoardDesc) (string, error) {
+	board, puzzles, err := prepare(bd)
+	if err != nil {
+		return "", err
+	}
+	type normalizedPuzzle struct {
+		Color  int
+		Blocks [][2]int
+	}
+	normalized := struct {
+		Version      int
+		W, H         int
+		XProj, YProj [][]int
+		Grid         [][]int
+		Puzzles      []normalizedPuzzle
+	}{
+		Version: solutionCacheVersion,
+		W:       board.XSize,
+		H:       board.YSize,
+		XProj:   board.XProj,
+		YProj:   board.YProj,
+		Grid:    board.Grid,
+		Puzzles: make([]normalizedPuzzle, len(puzzles)),
+	}
+	for i, p := range puzzles {
+		blocks := slices.Clone(p.Blocks)
+		slices.SortFunc(blocks, func(a, b [2]int) int {
+			if a[1] != b[1] {
+				return a[1] - b[1]
+			}
+			return a[0] - b[0]
+		})
+		normalized.Puzzles[i] = normalizedPuzzle{Color: p.Color, Blocks: blocks}
+	}
+
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func solutionCachePath(fingerprint string) string {
+	return filepath.Join(solutionCacheRoot, fmt.Sprintf(solutionCacheFile, fingerprint))
+}
+
+// loadCachedSolution returns the cached placements for the board, if any.
+// Placements that no longer solve the board are removed from the cache.
+func loadCachedSolution(bd *BoardDesc, fingerprint string) ([]Placement, bool) {
+	path := solutionCachePath(fingerprint)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var cached cachedSolution
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Version != solutionCacheVersion || cached.Fingerprint != fingerprint {
+		log.Warn().Err(err).Str("path", path).Msg("Ignoring invalid puzzle solution cache entry")
+		removeCachedSolution(path)
+		return nil, false
+	}
+	if err := VerifyPlacements(bd, cached.Placements); err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("Cached puzzle solution does not solve the board")
+		removeCachedSolution(path)
+		return nil, false
+	}
+	// Keep recently used entries from being pruned
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return cached.Placements, true
+}
+
+// saveCachedSolution stores the placements chosen for the board, removing the least recently used
+// entries beyond PUZZLE_SOLUTION_CACHE_MAX_ENTRIES
+func saveCachedSolution(fingerprint string, placements []Placement) {
+	if err := os.MkdirAll(solutionCacheRoot, 0755); err != nil {
+		log.Warn().Err(err).Str("dir", solutionCacheRoot).Msg("Failed to create puzzle solution cache directory")
+		return
+	}
+	data, err := json.MarshalIndent(&cachedSolution{
+		Version:     solutionCacheVersion,
+		Fingerprint: fingerprint,
+		SolvedAt:    time.Now(),
+		Placements:  placements,
+	}, "", "  ")
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode puzzle solution cache entry")
+		return
+	}
+	path := solutionCachePath(fingerprint)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("Failed to write puzzle solution cache entry")
+		return
+	}
+	pruneSolutionCache(PUZZLE_SOLUTION_CACHE_MAX_ENTRIES)
+}
+
+func removeCachedSolution(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Warn().Err(err).Str("path", path).Msg("Failed to remove puzzle solution cache entry")
+	}
+}
+
+// pruneSolutionCache keeps the keep most recently used cache entries
+func pruneSolutionCache(keep int) {
+	entries, err := os.ReadDir(solutionCacheRoot)
+	if err != nil {
+		return
+	}
+	type entry struct {
+		path    string
+		modTime time.Time
+	}
+	var files []entry
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			files = append(files, entry{filepath.Join(solutionCacheRoot, e.Name()), info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for _, f := range files[min(keep, len(files)):] {
+		removeCachedSolution(f.path)
+	}
+}
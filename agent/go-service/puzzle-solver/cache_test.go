@@ -0,0 +1,108 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestBoardFingerprint(t *testing.T) {
+	load := func() *BoardDesc {
+		bd, err := LoadBoardDesc(filepath.Join("testdata", "boards", "medium_5x6_3hue.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bd
+	}
+	fingerprint := func(bd *BoardDesc) string {
+		fp, err := BoardFingerprint(bd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fp
+	}
+	want := fingerprint(load())
+
+	// The same layout recognized again, on another tab and with slightly different colors
+	bd := load()
+	bd.Tab, bd.Confidence = 1, 0.5
+	for _, pd := range bd.PuzzleList {
+		pd.Hue += 2
+		slices.Reverse(pd.Blocks)
+	}
+	for _, bb := range bd.BannedBlockList {
+		bb.RawLoc[0]++
+	}
+	if got := fingerprint(bd); got != want {
+		t.Errorf("fingerprint changed with the recognition details: %s, want %s", got, want)
+	}
+
+	for name, change := range map[string]func(bd *BoardDesc){
+		"projection":   func(bd *BoardDesc) { bd.ProjDescList[0].XProjList[0]++ },
+		"puzzle shape": func(bd *BoardDesc) { bd.PuzzleList[0].Blocks = bd.PuzzleList[0].Blocks[1:] },
+		"puzzle order": func(bd *BoardDesc) { bd.PuzzleList[0], bd.PuzzleList[1] = bd.PuzzleList[1], bd.PuzzleList[0] },
+		"banned cell":  func(bd *BoardDesc) { bd.BannedBlockList = bd.BannedBlockList[1:] },
+	} {
+		bd := load()
+		change(bd)
+		if fingerprint(bd) == want {
+			t.Errorf("fingerprint unchanged with another %s", name)
+		}
+	}
+}
+
+func TestSolutionCache(t *testing.T) {
+	bd, err := LoadBoardDesc(filepath.Join("testdata", "boards", "small_3x3_1hue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	placements, err := Solve(context.Background(), bd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp, err := BoardFingerprint(bd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	if _, ok := loadCachedSolution(bd, fp); ok {
+		t.Fatal("hit in an empty cache")
+	}
+	saveCachedSolution(fp, placements)
+	got, ok := loadCachedSolution(bd, fp)
+	if !ok || !reflect.DeepEqual(got, placements) {
+		t.Fatalf("cached %v, %v, want %v", got, ok, placements)
+	}
+
+	// Placements that do not solve the board are dropped
+	saveCachedSolution(fp, placements[1:])
+	if _, ok := loadCachedSolution(bd, fp); ok {
+		t.Error("hit with invalid placements")
+	}
+	if _, err := os.Stat(solutionCachePath(fp)); !os.IsNotExist(err) {
+		t.Errorf("invalid entry still cached: %v", err)
+	}
+}
+
+func TestPruneSolutionCache(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(keep int) { PUZZLE_SOLUTION_CACHE_MAX_ENTRIES = keep }(PUZZLE_SOLUTION_CACHE_MAX_ENTRIES)
+	PUZZLE_SOLUTION_CACHE_MAX_ENTRIES = 3
+
+	for i := range 5 {
+		saveCachedSolution(fmt.Sprintf("entry%d", i), nil)
+	}
+	entries, err := os.ReadDir(solutionCacheRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("%d entries kept, want 3", len(entries))
+	}
+}
@@ -1,6 +1,8 @@
 // Copyright (c) 2026 Harry Huang
 package puzzle
 
+// Work space that every value below is defined in,
+// screenshots of other 16:9 sizes are resampled into it (see coordTransform)
 const (
 	WORK_W = 1280
 	WORK_H = 720
@@ -19,6 +21,14 @@ var (
 	PUZZLE_HUE_DIFF_GRT        = 24
 )
 
+// Puzzle thumbnail panel paging parameters
+var (
+	PUZZLE_THUMB_MAX_PAGES        = 4
+	PUZZLE_THUMB_SCROLL_STEP      = 120 // One mouse wheel notch
+	PUZZLE_THUMB_SCROLLS_PER_PAGE = 4   // Wheel notches to scroll the panel by one page
+	PUZZLE_THUMB_PAGE_DIFF_GRT    = 4.0 // Mean channel difference telling two pages apart
+)
+
 // Puzzle preview parameters
 var (
 	PUZZLE_W                   = 0.048 * float64(WORK_W)
@@ -48,12 +58,33 @@ var (
 
 // Projection figure parameters
 var (
-	PROJ_X_FIGURE_H    = 1.25 * BOARD_BLOCK_W
-	PROJ_Y_FIGURE_W    = 1.25 * BOARD_BLOCK_H
-	PROJ_COLOR_SAT_GRT = 0.50
-	PROJ_COLOR_VAL_GRT = 0.30
-	PROJ_INIT_GAP      = 0.007 * float64(WORK_H)
-	PROJ_EACH_GAP      = 0.013 * float64(WORK_H)
+	PROJ_X_FIGURE_H         = 1.25 * BOARD_BLOCK_W
+	PROJ_Y_FIGURE_W         = 1.25 * BOARD_BLOCK_H
+	PROJ_COLOR_SAT_GRT      = 0.50
+	PROJ_COLOR_VAL_GRT      = 0.30
+	PROJ_INIT_GAP           = 0.007 * float64(WORK_H)
+	PROJ_EACH_GAP           = 0.013 * float64(WORK_H)
+	PROJ_COMPONENT_MIN_AREA = 8 // Smaller connected areas of the target hue are noise rather than bars
+
+	// Highest confidence of a projection reading whose two readers disagree. The board confidence is
+	// capped at such a reading's confidence, so it must stay below PUZZLE_MIN_CONFIDENCE.
+	PROJ_DISAGREE_MAX_CONFIDENCE = 0.5
+)
+
+// Recognition parameters
+var (
+	PUZZLE_RECOGNITION_MAX_ATTEMPTS = 3    // Recognize again on a fresh screenshot if validation fails
+	PUZZLE_MIN_CONFIDENCE           = 0.95 // PuzzleAction refuses boards below this confidence by default
+	TEMPLATE_MATCH_THRESHOLD        = 0.7
+)
+
+// Action parameters
+var (
+	PUZZLE_PLACE_MAX_ATTEMPTS         = 3    // Place a piece again if it did not land on its target
+	PUZZLE_RESOLVE_MAX_ROUNDS         = 2    // Recognize and solve again if the puzzle is not complete after all pieces
+	PUZZLE_COMPLETE_WAIT_MS           = 3000 // How long to wait for the completion tip
+	PUZZLE_RECORD_MAX_SESSIONS        = 10   // Recorded sessions kept under debug/puzzle/, the oldest are removed
+	PUZZLE_SOLUTION_CACHE_MAX_ENTRIES = 200  // Solutions kept under cache/puzzle/, the least recently used are removed
 )
 
 // Other UI parameters
@@ -63,4 +94,6 @@ var (
 	TAB_Y   = 0.910 * float64(WORK_H)
 	TAB_W   = 0.029 * float64(WORK_W)
 	TAB_H   = 0.029 * float64(WORK_H)
+
+	TAB_X_LIST = []float64{TAB_1_X, TAB_2_X} // Board tabs, in the order the Tab key cycles through
 )
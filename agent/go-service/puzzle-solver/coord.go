@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"image"
+	"math"
+	"sync/atomic"
+)
+
+// coordTransform maps between the work space (WORK_W x WORK_H), where every constant is defined,
+// and the screen space of the real screenshots and touch targets, e.g. 1920x1080 or 2560x1440.
+// Screenshots are resampled into the work space once they are captured,
+// and touch targets are scaled back to the screen space right before they are sent.
+type coordTransform struct {
+	screenW, screenH int
+	sx, sy           float64 // Screen pixels per work pixel
+}
+
+func newCoordTransform(screenW, screenH int) *coordTransform {
+	if screenW <= 0 || screenH <= 0 {
+		screenW, screenH = WORK_W, WORK_H
+	}
+	// Other aspect ratios are stopped by the aspectratio checker before the task starts
+	return &coordTransform{
+		screenW: screenW,
+		screenH: screenH,
+		sx:      float64(screenW) / float64(WORK_W),
+		sy:      float64(screenH) / float64(WORK_H),
+	}
+}
+
+// getImageTransform returns the transform for screenshots of the image's size
+func getImageTransform(img image.Image) *coordTransform {
+	if img == nil {
+		return newCoordTransform(WORK_W, WORK_H)
+	}
+	return newCoordTransform(img.Bounds().Dx(), img.Bounds().Dy())
+}
+
+// screenTransform is the transform of the latest live screenshot, kept for the touches that follow it
+var screenTransform atomic.Pointer[coordTransform]
+
+// normalizeScreen resamples a live screenshot into the work space and keeps its transform.
+// MaaFramework already delivers screenshots and takes touches at 720p by default,
+// so this is the identity unless the controller is set up for another resolution.
+func normalizeScreen(img image.Image) image.Image {
+	t := getImageTransform(img)
+	screenTransform.Store(t)
+	return t.normalizeImage(img)
+}
+
+// getScreenTransform returns the transform of the latest live screenshot
+func getScreenTransform() *coordTransform {
+	if t := screenTransform.Load(); t != nil {
+		return t
+	}
+	return newCoordTransform(WORK_W, WORK_H)
+}
+
+func (t *coordTransform) isIdentity() bool {
+	return t.screenW == WORK_W && t.screenH == WORK_H
+}
+
+// toScreen converts a work space point to the screen space
+func (t *coordTransform) toScreen(x, y int) (int, int) {
+	return int(math.Round(float64(x) * t.sx)), int(math.Round(float64(y) * t.sy))
+}
+
+// toWork converts a screen space point to the work space
+func (t *coordTransform) toWork(x, y int) (int, int) {
+	return int(math.Round(float64(x) / t.sx)), int(math.Round(float64(y) / t.sy))
+}
+
+// rectToScreen converts a work space rect to the screen space
+func (t *coordTransform) rectToScreen(rect image.Rectangle) image.Rectangle {
+	x1, y1 := t.toScreen(rect.Min.X, rect.Min.Y)
+	x2, y2 := t.toScreen(rect.Max.X, rect.Max.Y)
+	return image.Rect(x1, y1, x2, y2)
+}
+
+// normalizeImage resamples a screenshot into the work space by area averaging
+func (t *coordTransform) normalizeImage(img image.Image) image.Image {
+	if img == nil || t.isIdentity() {
+		return img
+	}
+
+	src := newRGBPlanes(img, img.Bounds())
+	xs := getResampleWeights(src.w, WORK_W)
+	ys := getResampleWeights(src.h, WORK_H)
+
+	dst := image.NewRGBA(image.Rect(0, 0, WORK_W, WORK_H))
+	row := make([]float64, src.w)
+	for k := range src.c {
+		for y, yw := range ys {
+			// Vertical pass into a single row, then horizontal pass into the destination
+			for x := range row {
+				row[x] = 0
+			}
+			for _, w := range yw {
+				line := src.c[k][w.i*src.w : (w.i+1)*src.w]
+				for x, v := range line {
+					row[x] += v * w.w
+				}
+			}
+			for x, xw := range xs {
+				v := 0.0
+				for _, w := range xw {
+					v += row[w.i] * w.w
+				}
+				dst.Pix[dst.PixOffset(x, y)+k] = uint8(min(255, max(0, v+0.5)))
+			}
+		}
+	}
+	for i := 3; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = 255
+	}
+	return dst
+}
+
+type resampleWeight struct {
+	i int
+	w float64
+}
+
+// getResampleWeights returns, for each destination pixel, the source pixels it covers and their weights
+func getResampleWeights(srcN, dstN int) [][]resampleWeight {
+	scale := float64(srcN) / float64(dstN)
+	result := make([][]resampleWeight, dstN)
+	for d := range result {
+		lo, hi := float64(d)*scale, float64(d+1)*scale
+		if scale < 1 {
+			// Upscaling: sample the single source pixel under the destination center
+			result[d] = []resampleWeight{{min(int((lo+hi)/2), srcN-1), 1}}
+			continue
+		}
+		for s := int(lo); s < srcN && float64(s) < hi; s++ {
+			w := math.Min(hi, float64(s+1)) - math.Max(lo, float64(s))
+			if w > 0 {
+				result[d] = append(result[d], resampleWeight{s, w / scale})
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testResolutions are the 16:9 capture sizes recognition must handle alike
+var testResolutions = [][2]int{{1280, 720}, {1920, 1080}, {2560, 1440}, {960, 540}}
+
+// scaleImage resizes img as a capture at another resolution would: the game renders a smaller screen
+// with its details averaged out, and a larger one with each work pixel spread over several pixels
+func scaleImage(img image.Image, w, h int) image.Image {
+	src := newRGBPlanes(img, img.Bounds())
+	xs := getResampleWeights(src.w, w)
+	ys := getResampleWeights(src.h, h)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y, yw := range ys {
+		for x, xw := range xs {
+			off := dst.PixOffset(x, y)
+			for k := range src.c {
+				v := 0.0
+				for _, wy := range yw {
+					for _, wx := range xw {
+						v += src.c[k][wy.i*src.w+wx.i] * wy.w * wx.w
+					}
+				}
+				dst.Pix[off+k] = uint8(min(255, v+0.5))
+			}
+			dst.Pix[off+3] = 255
+		}
+	}
+	return dst
+}
+
+func TestCoordTransform(t *testing.T) {
+	for _, res := range testResolutions {
+		tr := newCoordTransform(res[0], res[1])
+		x, y := tr.toScreen(WORK_W, WORK_H)
+		if x != res[0] || y != res[1] {
+			t.Errorf("%v: work corner maps to (%d, %d)", res, x, y)
+		}
+		bx, by := getBlockCenter(2, 3, 5, 5)
+		sx, sy := tr.toScreen(bx, by)
+		if wx, wy := tr.toWork(sx, sy); abs(wx-bx) > 1 || abs(wy-by) > 1 {
+			t.Errorf("%v: block center (%d, %d) round trips to (%d, %d)", res, bx, by, wx, wy)
+		}
+		panel := tr.rectToScreen(getThumbPanelRect())
+		if want := float64(getThumbPanelRect().Dx()) * tr.sx; float64(panel.Dx()) < want-1 || float64(panel.Dx()) > want+1 {
+			t.Errorf("%v: panel width %d, want about %v", res, panel.Dx(), want)
+		}
+	}
+}
+
+func TestNormalizeImage(t *testing.T) {
+	img := newFilledImage(color.RGBA{30, 30, 30, 255})
+	rect := image.Rect(400, 200, 480, 260)
+	fillChecker(img, rect, color.RGBA{20, 90, 200, 255}, color.RGBA{80, 150, 255, 255})
+	wantHue, wantSat, wantVal := getAreaHSV(img, rect)
+
+	for _, res := range testResolutions {
+		screen := scaleImage(img, res[0], res[1])
+		work := getImageTransform(screen).normalizeImage(screen)
+		if work.Bounds() != image.Rect(0, 0, WORK_W, WORK_H) {
+			t.Fatalf("%v: normalized to %v", res, work.Bounds())
+		}
+		hue, sat, val := getAreaHSV(work, rect)
+		if diffHue(int(hue), int(wantHue)) > 2 || sat < wantSat-0.05 || val < wantVal-0.05 {
+			t.Errorf("%v: area HSV (%.0f, %.2f, %.2f), want (%.0f, %.2f, %.2f)", res, hue, sat, val, wantHue, wantSat, wantVal)
+		}
+	}
+}
+
+// scaledRecognizer shows the screens of another recognizer as if captured at another resolution
+type scaledRecognizer struct {
+	recognizer
+	w, h int
+}
+
+func (r *scaledRecognizer) capture(img image.Image) image.Image {
+	if img == nil {
+		return nil
+	}
+	screen := scaleImage(img, r.w, r.h)
+	return getImageTransform(screen).normalizeImage(screen)
+}
+
+func (r *scaledRecognizer) previewPuzzle(index, thumbX, thumbY int) image.Image {
+	return r.capture(r.recognizer.previewPuzzle(index, thumbX, thumbY))
+}
+
+func (r *scaledRecognizer) scrollThumbPage(page int) image.Image {
+	return r.capture(r.recognizer.scrollThumbPage(page))
+}
+
+func TestGetAllPuzzleDescResolutions(t *testing.T) {
+	var want []*PuzzleDesc
+	for _, res := range testResolutions {
+		r := &scaledRecognizer{&pagingRecognizer{n: 9}, res[0], res[1]}
+		got := getAllPuzzleDesc(r, r.scrollThumbPage(0), defaultPalette)
+		if len(got) != 9 {
+			t.Fatalf("%v: got %d puzzles, want 9", res, len(got))
+		}
+		if want == nil {
+			want = got
+			continue
+		}
+		for i := range got {
+			if !reflect.DeepEqual(got[i].Blocks, want[i].Blocks) || got[i].Page != want[i].Page || got[i].Slot != want[i].Slot ||
+				diffHue(got[i].Hue, want[i].Hue) > 2 {
+				t.Errorf("%v: puzzle %d is %+v, want %+v", res, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+// writeScaledFixture copies a recorded screen directory, resizing its screenshots
+func writeScaledFixture(t *testing.T, dir string, w, h int) string {
+	t.Helper()
+	out := t.TempDir()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		img, err := loadPNG(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Create(filepath.Join(out, filepath.Base(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(f, scaleImage(img, w, h)); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	return out
+}
+
+// TestRecognizeRecordedScreensResolutions runs every recorded screen at several resolutions.
+// Pixel positions and hues may shift slightly by resampling, everything read off the board must not.
+func TestRecognizeRecordedScreensResolutions(t *testing.T) {
+	for _, dir := range screenFixtureDirs(t) {
+		want, err := LoadBoardDesc(filepath.Join(dir, fixtureGoldenFile))
+		if err != nil {
+			t.Fatalf("load golden: %v", err)
+		}
+		for _, res := range testResolutions {
+			got, err := RecognizeFixture(writeScaledFixture(t, dir, res[0], res[1]), resourceDir)
+			if err != nil {
+				t.Fatalf("%s at %v: %v", filepath.Base(dir), res, err)
+			}
+			if got.W != want.W || got.H != want.H || !reflect.DeepEqual(got.ProjDescList, want.ProjDescList) ||
+				len(got.PuzzleList) != len(want.PuzzleList) || len(got.BannedBlockList) != len(want.BannedBlockList) {
+				t.Errorf("%s at %v: board desc mismatch", filepath.Base(dir), res)
+				continue
+			}
+			for i := range got.PuzzleList {
+				if !reflect.DeepEqual(got.PuzzleList[i].Blocks, want.PuzzleList[i].Blocks) {
+					t.Errorf("%s at %v: puzzle %d blocks %v, want %v", filepath.Base(dir), res, i,
+						got.PuzzleList[i].Blocks, want.PuzzleList[i].Blocks)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"math"
+	"sort"
+)
+
+// costPerRotationPress weighs one R press against dragging a piece one board block further
+const costPerRotationPress = 1.0
+
+// maxPermutedGroupSize bounds the identical puzzle groups whose assignments are permuted
+const maxPermutedGroupSize = 6
+
+// getRotationPresses returns how many R presses doPlace needs for the rotation
+// Mapping: 0->0, 1->3, 2->2, 3->1
+func getRotationPresses(rotation int) int {
+	return (4 - ((rotation%4)+4)%4) % 4
+}
+
+// placementCost estimates the input cost of one placement:
+// the drag distance from its thumbnail to its target (in board blocks) plus its R presses.
+func placementCost(bd *BoardDesc, p Placement) float64 {
+	_, slot := bd.getThumbSlot(p.PuzzleIndex)
+	sx, sy := getThumbCenter(slot)
+	ex, ey := getBlockCenter(p.MachineX, p.MachineY, bd.W, bd.H)
+	dist := math.Hypot(float64(ex-sx)/BOARD_BLOCK_W, float64(ey-sy)/BOARD_BLOCK_H)
+	return dist + costPerRotationPress*float64(getRotationPresses(p.Rotation))
+}
+
+// SolutionCost estimates the total input cost of executing the placements
+func SolutionCost(bd *BoardDesc, placements []Placement) float64 {
+	cost := 0.0
+	for _, p := range placements {
+		cost += placementCost(bd, p)
+	}
+	return cost
+}
+
+// getFootprint returns the board cells covered by the placement, sorted row-major
+func getFootprint(pz *Puzzle, p Placement) [][2]int {
+	deriv := pz.getAllDerivatives()[((p.Rotation%4)+4)%4]
+	cells := make([][2]int, len(deriv.Blocks))
+	for i, b := range deriv.Blocks {
+		cells[i] = [2]int{p.MachineX + b[0], p.MachineY + b[1]}
+	}
+	sortCells(cells)
+	return cells
+}
+
+func sortCells(cells [][2]int) {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i][1] != cells[j][1] {
+			return cells[i][1] < cells[j][1]
+		}
+		return cells[i][0] < cells[j][0]
+	})
+}
+
+// getCheapestPlacementFor returns the cheapest placement of the puzzle covering exactly the footprint,
+// considering every rotation (including those dropped by getUniqueDerivatives).
+func getCheapestPlacementFor(bd *BoardDesc, pz *Puzzle, footprint [][2]int) (Placement, float64, bool) {
+	best, bestCost, found := Placement{}, 0.0, false
+	for _, deriv := range pz.getAllDerivatives() {
+		if len(deriv.Blocks) != len(footprint) || len(footprint) == 0 {
+			continue
+		}
+		blocks := make([][2]int, len(deriv.Blocks))
+		copy(blocks, deriv.Blocks)
+		sortCells(blocks)
+
+		// Anchor so that the first blocks coincide, then check the rest
+		ax, ay := footprint[0][0]-blocks[0][0], footprint[0][1]-blocks[0][1]
+		match := true
+		for i, b := range blocks {
+			if b[0]+ax != footprint[i][0] || b[1]+ay != footprint[i][1] {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+
+		p := Placement{MachineX: ax, MachineY: ay, Rotation: deriv.Rotation, PuzzleIndex: pz.Index}
+		if c := placementCost(bd, p); !found || c < bestCost {
+			best, bestCost, found = p, c, true
+		}
+	}
+	return best, bestCost, found
+}
+
+// optimizePlacements rewrites a solution into the cheapest equivalent one.
+// Each puzzle keeps its covered cells but takes its cheapest rotation and anchor,
+// and identical puzzles may swap their cells, as the search only yields one order of them.
+func optimizePlacements(bd *BoardDesc, puzzles []*Puzzle, placements []Placement) []Placement {
+	result := make([]Placement, len(puzzles))
+	footprints := make([][][2]int, len(puzzles))
+	for _, p := range placements {
+		result[p.PuzzleIndex] = p
+		footprints[p.PuzzleIndex] = getFootprint(puzzles[p.PuzzleIndex], p)
+	}
+
+	// Group identical puzzles by following their chains
+	prev := getIdenticalPrev(puzzles)
+	groups := make(map[int][]int)
+	for i := range puzzles {
+		root := i
+		for prev[root] >= 0 {
+			root = prev[root]
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	for _, members := range groups {
+		if len(members) > maxPermutedGroupSize {
+			// Too many to permute, only pick the cheapest rotation of each
+			for _, i := range members {
+				if p, _, ok := getCheapestPlacementFor(bd, puzzles[i], footprints[i]); ok {
+					result[i] = p
+				}
+			}
+			continue
+		}
+
+		// Try every assignment of the group's footprints to its members
+		cells := make([][][2]int, len(members))
+		for k, i := range members {
+			cells[k] = footprints[i]
+		}
+		bestCost := math.Inf(1)
+		var bestAssign []Placement
+		permute(len(members), func(perm []int) {
+			assign := make([]Placement, len(members))
+			cost := 0.0
+			for k, i := range members {
+				p, c, ok := getCheapestPlacementFor(bd, puzzles[i], cells[perm[k]])
+				if !ok {
+					return
+				}
+				assign[k] = p
+				cost += c
+			}
+			if cost < bestCost {
+				bestCost = cost
+				bestAssign = assign
+			}
+		})
+		for k, i := range members {
+			if bestAssign != nil {
+				result[i] = bestAssign[k]
+			}
+		}
+	}
+	return result
+}
+
+// permute calls fn with every permutation of [0, n)
+func permute(n int, fn func([]int)) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	var gen func(k int)
+	gen = func(k int) {
+		if k == n {
+			fn(perm)
+			return
+		}
+		for i := k; i < n; i++ {
+			perm[k], perm[i] = perm[i], perm[k]
+			gen(k + 1)
+			perm[k], perm[i] = perm[i], perm[k]
+		}
+	}
+	gen(0)
+}
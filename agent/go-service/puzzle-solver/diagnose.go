@@ -0,0 +1,155 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"fmt"
+)
+
+// Diagnosis names one constraint of the board that cannot be satisfied
+type Diagnosis struct {
+	Hue      int    // Hue value from HueList, -1 if not applicable
+	Kind     string // "total", "column", "row", "puzzle" or "space"
+	Index    int    // Column, row or puzzle index, -1 if not applicable
+	Expected int    // What the constraint asks for
+	Possible int    // What the board and puzzles can provide at most
+	Message  string // Human readable explanation
+}
+
+func (d Diagnosis) String() string {
+	return d.Message
+}
+
+// Diagnose explains why the board cannot be solved, by checking each constraint on its own.
+// An empty result means no single constraint fails, only their combination does.
+func Diagnose(bd *BoardDesc) ([]Diagnosis, error) {
+	board, puzzles, err := prepare(bd)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Diagnosis
+	add := func(hIdx int, kind string, index, expected, possible int, format string, args ...any) {
+		hue := -1
+		if hIdx >= 0 {
+			hue = bd.HueList[hIdx]
+		}
+		result = append(result, Diagnosis{
+			Hue:      hue,
+			Kind:     kind,
+			Index:    index,
+			Expected: expected,
+			Possible: possible,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// 1. Every puzzle must fit somewhere on the empty board
+	cands := make([][]candidate, len(puzzles))
+	for i, pz := range puzzles {
+		for _, deriv := range pz.getUniqueDerivatives() {
+			for y := 0; y < board.YSize; y++ {
+				for x := 0; x < board.XSize; x++ {
+					if c, ok := board.newCandidate(deriv, x, y); ok {
+						cands[i] = append(cands[i], c)
+					}
+				}
+			}
+		}
+		if len(cands[i]) == 0 {
+			add(pz.Color, "puzzle", i, 1, 0,
+				"puzzle %d (hue %d, %d blocks) fits nowhere on the board", i, bd.HueList[pz.Color], len(pz.Blocks))
+		}
+	}
+
+	// 2. There must be enough free cells for all puzzles
+	freeCells, pieceBlocks := 0, 0
+	for y := 0; y < board.YSize; y++ {
+		for x := 0; x < board.XSize; x++ {
+			if board.Grid[y][x] == -1 {
+				freeCells++
+			}
+		}
+	}
+	for _, pz := range puzzles {
+		pieceBlocks += len(pz.Blocks)
+	}
+	if pieceBlocks > freeCells {
+		add(-1, "space", -1, pieceBlocks, freeCells,
+			"puzzles need %d blocks, only %d free cells on the board", pieceBlocks, freeCells)
+	}
+
+	for h := 0; h < board.K; h++ {
+		hue := bd.HueList[h]
+
+		// 3. Totals: projections must agree with each other and with locked blocks plus puzzles
+		sumX, sumY, locked, blocks := 0, 0, 0, 0
+		for x := 0; x < board.XSize; x++ {
+			sumX += board.XProj[h][x]
+			locked += board.CurrXCounts[h][x]
+		}
+		for y := 0; y < board.YSize; y++ {
+			sumY += board.YProj[h][y]
+		}
+		for _, pz := range puzzles {
+			if pz.Color == h {
+				blocks += len(pz.Blocks)
+			}
+		}
+		if sumX != sumY {
+			add(h, "total", -1, sumX, sumY,
+				"hue %d column projections sum to %d, but row projections sum to %d", hue, sumX, sumY)
+		}
+		if sumX != locked+blocks {
+			add(h, "total", -1, sumX, locked+blocks,
+				"hue %d projections expect %d blocks, locked blocks and puzzles give %d (%d locked + %d from puzzles)",
+				hue, sumX, locked+blocks, locked, blocks)
+		}
+
+		// 4. Lines: each projection must be reachable by locked blocks plus the puzzles of this hue
+		maxX := make([]int, board.XSize)
+		maxY := make([]int, board.YSize)
+		for i, pz := range puzzles {
+			if pz.Color != h {
+				continue
+			}
+			pieceX := make([]int, board.XSize)
+			pieceY := make([]int, board.YSize)
+			for _, c := range cands[i] {
+				for _, lc := range c.colCounts {
+					pieceX[lc[0]] = max(pieceX[lc[0]], lc[1])
+				}
+				for _, lc := range c.rowCounts {
+					pieceY[lc[0]] = max(pieceY[lc[0]], lc[1])
+				}
+			}
+			for x, v := range pieceX {
+				maxX[x] += v
+			}
+			for y, v := range pieceY {
+				maxY[y] += v
+			}
+		}
+		for x := 0; x < board.XSize; x++ {
+			expected, lockedX := board.XProj[h][x], board.CurrXCounts[h][x]
+			if lockedX > expected {
+				add(h, "column", x, expected, lockedX,
+					"hue %d column %d expects %d, but %d blocks are already locked", hue, x, expected, lockedX)
+			} else if possible := lockedX + maxX[x]; possible < expected {
+				add(h, "column", x, expected, possible,
+					"hue %d column %d expects %d, only %d possible", hue, x, expected, possible)
+			}
+		}
+		for y := 0; y < board.YSize; y++ {
+			expected, lockedY := board.YProj[h][y], board.CurrYCounts[h][y]
+			if lockedY > expected {
+				add(h, "row", y, expected, lockedY,
+					"hue %d row %d expects %d, but %d blocks are already locked", hue, y, expected, lockedY)
+			} else if possible := lockedY + maxY[y]; possible < expected {
+				add(h, "row", y, expected, possible,
+					"hue %d row %d expects %d, only %d possible", hue, y, expected, possible)
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseSolvableBoards(t *testing.T) {
+	for name, bd := range loadFixtureBoards(t) {
+		diagnoses, err := Diagnose(bd)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(diagnoses) != 0 {
+			t.Errorf("%s: unexpected diagnoses %v", name, diagnoses)
+		}
+	}
+}
+
+func TestDiagnoseColumnShortage(t *testing.T) {
+	bd := &BoardDesc{
+		W: 3,
+		H: 1,
+		ProjDescList: []ProjDesc{
+			{XProjList: []int{1, 2, 0}, YProjList: []int{2}},
+		},
+		LockedBlockList: [][]*LockedBlockDesc{{}},
+		PuzzleList: []*PuzzleDesc{
+			{Blocks: [][2]int{{0, 0}, {1, 0}}, Hue: 206},
+		},
+		HueList: []int{206},
+	}
+	diagnoses, err := Diagnose(bd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diagnoses {
+		if d.Kind == "column" && d.Index == 1 {
+			if !strings.Contains(d.Message, "hue 206 column 1 expects 2, only 1 possible") {
+				t.Errorf("unexpected message %q", d.Message)
+			}
+			return
+		}
+	}
+	t.Fatalf("column 1 shortage not reported: %v", diagnoses)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// GenerateOptions controls the random boards made by GenerateBoard
+type GenerateOptions struct {
+	MinSize, MaxSize int     // Range of the board width and height
+	MaxHues          int     // Hues are picked from the palette, 1 to MaxHues of them
+	MaxPieceSize     int     // Pieces have 1 to MaxPieceSize blocks
+	MaxPieces        int     // At most this many pieces, and never more than the thumbnail panel holds
+	BannedRatio      float64 // Fraction of cells banned
+	LockedRatio      float64 // Fraction of cells locked, among those left by the pieces
+	FillRatio        float64 // Fraction of unbanned cells covered by pieces, at most
+}
+
+// GeneratePresets are the board families used by tests, benchmarks and "puzzle generate".
+// "hard" boards have many small pieces, which takes the backtrack engine a while.
+var GeneratePresets = map[string]GenerateOptions{
+	"small":  {MinSize: 3, MaxSize: 4, MaxHues: 1, MaxPieceSize: 3, MaxPieces: 4, BannedRatio: 0.1, LockedRatio: 0.1, FillRatio: 0.7},
+	"medium": {MinSize: 4, MaxSize: 6, MaxHues: 2, MaxPieceSize: 4, MaxPieces: 6, BannedRatio: 0.1, LockedRatio: 0.1, FillRatio: 0.7},
+	"large":  {MinSize: 6, MaxSize: 7, MaxHues: 3, MaxPieceSize: 5, MaxPieces: 8, BannedRatio: 0.08, LockedRatio: 0.1, FillRatio: 0.7},
+	"hard":   {MinSize: 7, MaxSize: 7, MaxHues: 2, MaxPieceSize: 4, MaxPieces: 11, BannedRatio: 0.05, LockedRatio: 0.05, FillRatio: 0.8},
+}
+
+// GeneratePresetNames lists the names of GeneratePresets in order
+func GeneratePresetNames() []string {
+	names := make([]string, 0, len(GeneratePresets))
+	for name := range GeneratePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GenerateBoard makes a random solvable board: it lays random polyomino pieces on a random board
+// with banned and locked cells, and derives the projections from them.
+// It returns the board and the hidden solution it was made from.
+func GenerateBoard(rng *rand.Rand, opts GenerateOptions) (*BoardDesc, []Placement) {
+	w := opts.MinSize + rng.Intn(opts.MaxSize-opts.MinSize+1)
+	h := opts.MinSize + rng.Intn(opts.MaxSize-opts.MinSize+1)
+	hues := make([]int, len(defaultPalette.Colors))
+	for i, c := range defaultPalette.Colors {
+		hues[i] = c.Hue
+	}
+	rng.Shuffle(len(hues), func(i, j int) { hues[i], hues[j] = hues[j], hues[i] })
+	k := 1 + rng.Intn(min(opts.MaxHues, len(hues)))
+
+	// Same conventions as Board.Grid: -1 empty, -2 banned, hue index otherwise
+	grid := make([][]int, h)
+	for y := range grid {
+		grid[y] = make([]int, w)
+		for x := range grid[y] {
+			grid[y][x] = -1
+		}
+	}
+	emptyCells := func() [][2]int {
+		var cells [][2]int
+		for y := range h {
+			for x := range w {
+				if grid[y][x] == -1 {
+					cells = append(cells, [2]int{x, y})
+				}
+			}
+		}
+		rng.Shuffle(len(cells), func(i, j int) { cells[i], cells[j] = cells[j], cells[i] })
+		return cells
+	}
+
+	// 1. Banned cells
+	for _, c := range emptyCells()[:int(opts.BannedRatio*float64(w*h))] {
+		grid[c[1]][c[0]] = -2
+	}
+
+	// 2. Pieces, grown from random cells until the board is filled enough
+	free := len(emptyCells())
+	target := max(1, int(opts.FillRatio*float64(free)))
+	maxPieces := PUZZLE_THUMB_MAX_PAGES * PUZZLE_THUMB_MAX_ROWS * PUZZLE_THUMB_MAX_COLS
+	if opts.MaxPieces > 0 {
+		maxPieces = min(maxPieces, opts.MaxPieces)
+	}
+	var pieces [][][2]int
+	var pieceHues []int
+	covered := 0
+	for attempt := 0; covered < target && len(pieces) < maxPieces && attempt < 4*w*h; attempt++ {
+		cells := emptyCells()
+		if len(cells) == 0 {
+			break
+		}
+		blocks := growPiece(rng, grid, cells[0], 1+rng.Intn(min(opts.MaxPieceSize, target-covered)))
+		hue := len(pieces) % k // Every hue gets a piece first
+		if len(pieces) >= k {
+			hue = rng.Intn(k)
+		}
+		for _, b := range blocks {
+			grid[b[1]][b[0]] = hue
+		}
+		pieces = append(pieces, blocks)
+		pieceHues = append(pieceHues, hue)
+		covered += len(blocks)
+	}
+	k = min(k, len(pieces))
+	hues = hues[:k]
+
+	// 3. Locked cells among those left
+	cells := emptyCells()
+	locked := make([][2]int, 0)
+	for _, c := range cells[:int(opts.LockedRatio*float64(len(cells)))] {
+		grid[c[1]][c[0]] = rng.Intn(k)
+		locked = append(locked, c)
+	}
+
+	// 4. Describe the board
+	bd := &BoardDesc{
+		Version:         BoardDescVersion,
+		W:               w,
+		H:               h,
+		ProjDescList:    make([]ProjDesc, k),
+		LockedBlockList: make([][]*LockedBlockDesc, k),
+		HueList:         hues,
+	}
+	for hIdx := range k {
+		bd.ProjDescList[hIdx] = ProjDesc{XProjList: make([]int, w), YProjList: make([]int, h)}
+		bd.LockedBlockList[hIdx] = []*LockedBlockDesc{}
+	}
+	for y := range h {
+		for x := range w {
+			ltX, ltY := convertBoardCoordToLTCoord(x, y, w, h)
+			switch hIdx := grid[y][x]; {
+			case hIdx == -2:
+				bd.BannedBlockList = append(bd.BannedBlockList, &BannedBlockDesc{Loc: [2]int{x, y}, RawLoc: [2]int{ltX, ltY}})
+			case hIdx >= 0:
+				bd.ProjDescList[hIdx].XProjList[x]++
+				bd.ProjDescList[hIdx].YProjList[y]++
+			}
+		}
+	}
+	for _, c := range locked {
+		hIdx := grid[c[1]][c[0]]
+		ltX, ltY := convertBoardCoordToLTCoord(c[0], c[1], w, h)
+		bd.LockedBlockList[hIdx] = append(bd.LockedBlockList[hIdx], &LockedBlockDesc{
+			Loc:    c,
+			RawLoc: [2]int{ltX, ltY},
+			Hue:    hues[hIdx],
+		})
+	}
+
+	// 5. Describe the pieces in a random rotation, relative to their core block
+	pageSize := PUZZLE_THUMB_MAX_ROWS * PUZZLE_THUMB_MAX_COLS
+	solution := make([]Placement, len(pieces))
+	for i, blocks := range pieces {
+		core := getCoreBlock(blocks)
+		placed := &Puzzle{Blocks: make([][2]int, len(blocks))}
+		for j, b := range blocks {
+			placed.Blocks[j] = [2]int{b[0] - core[0], b[1] - core[1]}
+		}
+		// The piece shown in rotation 0 is the placed one turned back by the rotation
+		rotation := rng.Intn(4)
+		shown := placed.getAllDerivatives()[(4-rotation)%4]
+		bd.PuzzleList = append(bd.PuzzleList, &PuzzleDesc{
+			Blocks: shown.Blocks,
+			Hue:    hues[pieceHues[i]],
+			Page:   i / pageSize,
+			Slot:   i % pageSize,
+		})
+		solution[i] = Placement{MachineX: core[0], MachineY: core[1], Rotation: rotation, PuzzleIndex: i}
+	}
+	_, bd.Confidence = ValidateBoardDesc(bd)
+	return bd, solution
+}
+
+// growPiece grows a random connected piece of up to size empty cells from start
+func growPiece(rng *rand.Rand, grid [][]int, start [2]int, size int) [][2]int {
+	blocks := [][2]int{start}
+	inPiece := map[[2]int]bool{start: true}
+	for len(blocks) < size {
+		var frontier [][2]int
+		for _, b := range blocks {
+			for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				n := [2]int{b[0] + d[0], b[1] + d[1]}
+				if n[1] < 0 || n[1] >= len(grid) || n[0] < 0 || n[0] >= len(grid[0]) ||
+					grid[n[1]][n[0]] != -1 || inPiece[n] {
+					continue
+				}
+				// The preview shows at most PUZZLE_MAX_EXTENT_ONE_SIDE blocks on each side of the core
+				if abs(n[0]-start[0]) > PUZZLE_MAX_EXTENT_ONE_SIDE || abs(n[1]-start[1]) > PUZZLE_MAX_EXTENT_ONE_SIDE {
+					continue
+				}
+				frontier = append(frontier, n)
+			}
+		}
+		if len(frontier) == 0 {
+			break
+		}
+		n := frontier[rng.Intn(len(frontier))]
+		blocks = append(blocks, n)
+		inPiece[n] = true
+	}
+	return blocks
+}
+
+func abs(v int) int {
+	return max(v, -v)
+}
+
+// getCoreBlock returns the block closest to the center of the piece, which the game shows as its core
+func getCoreBlock(blocks [][2]int) [2]int {
+	var sx, sy float64
+	for _, b := range blocks {
+		sx += float64(b[0])
+		sy += float64(b[1])
+	}
+	cx, cy := sx/float64(len(blocks)), sy/float64(len(blocks))
+	core, best := blocks[0], -1.0
+	for _, b := range blocks {
+		d := (float64(b[0])-cx)*(float64(b[0])-cx) + (float64(b[1])-cy)*(float64(b[1])-cy)
+		if best < 0 || d < best {
+			core, best = b, d
+		}
+	}
+	return core
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// generatedCorpus returns n boards of the preset, the same for the same seed
+func generatedCorpus(preset string, seed int64, n int) []*BoardDesc {
+	rng := rand.New(rand.NewSource(seed))
+	boards := make([]*BoardDesc, n)
+	for i := range boards {
+		boards[i], _ = GenerateBoard(rng, GeneratePresets[preset])
+	}
+	return boards
+}
+
+// presetNames lists the presets in a stable order
+func TestGenerateBoard(t *testing.T) {
+	for _, preset := range GeneratePresetNames() {
+		rng := rand.New(rand.NewSource(1))
+		for i := range 200 {
+			bd, solution := GenerateBoard(rng, GeneratePresets[preset])
+			if issues, _ := ValidateBoardDesc(bd); len(issues) > 0 {
+				t.Fatalf("%s #%d: invalid board: %v", preset, i, issues)
+			}
+			if err := VerifyPlacements(bd, solution); err != nil {
+				t.Fatalf("%s #%d: hidden solution: %v", preset, i, err)
+			}
+			for j, pd := range bd.PuzzleList {
+				for _, b := range pd.Blocks {
+					if abs(b[0]) > PUZZLE_MAX_EXTENT_ONE_SIDE || abs(b[1]) > PUZZLE_MAX_EXTENT_ONE_SIDE {
+						t.Fatalf("%s #%d: puzzle %d does not fit the preview: %v", preset, i, j, pd.Blocks)
+					}
+				}
+			}
+		}
+	}
+
+	a, _ := GenerateBoard(rand.New(rand.NewSource(7)), GeneratePresets["medium"])
+	b, _ := GenerateBoard(rand.New(rand.NewSource(7)), GeneratePresets["medium"])
+	if fmt.Sprint(a.ProjDescList, a.HueList) != fmt.Sprint(b.ProjDescList, b.HueList) {
+		t.Error("the same seed generated different boards")
+	}
+}
+
+// TestSolveGeneratedBoards checks that every generated board is solved,
+// and that every solution returned reproduces the projections exactly.
+func TestSolveGeneratedBoards(t *testing.T) {
+	counts := map[string]int{"small": 200, "medium": 100, "large": 40, "hard": 10}
+	for _, preset := range GeneratePresetNames() {
+		for i, bd := range generatedCorpus(preset, 1, counts[preset]) {
+			for _, engine := range engines {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				result, err := SolveAll(ctx, bd, SolveOptions{Engine: engine, ExtraSearchTime: 100 * time.Millisecond})
+				cancel()
+				if err != nil {
+					t.Fatalf("%s #%d with %s: %v", preset, i, engine, err)
+				}
+				for j, placements := range result.Solutions {
+					if err := VerifyPlacements(bd, placements); err != nil {
+						t.Fatalf("%s #%d with %s, solution %d: %v", preset, i, engine, j, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkGeneratedBoards(b *testing.B) {
+	for _, preset := range GeneratePresetNames() {
+		corpus := generatedCorpus(preset, 1, 20)
+		b.Run(preset, func(b *testing.B) {
+			for b.Loop() {
+				for _, bd := range corpus {
+					if _, err := SolveWithOptions(context.Background(), bd, SolveOptions{}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoSolution         = errors.New("no solution found")
+	ErrNodeBudgetExceeded = errors.New("node budget exceeded")
+
+	// errSearchDeadline stops a search at the deadline set by setDeadline, see SolveAll
+	errSearchDeadline = errors.New("search deadline reached")
+)
+
+// SolveProgress is a snapshot of an ongoing search
+type SolveProgress struct {
+	Nodes    int           // Search nodes explored so far
+	Depth    int           // Number of puzzles placed at the current node
+	MaxDepth int           // Deepest number of puzzles placed so far
+	Elapsed  time.Duration // Time since the search started
+}
+
+const (
+	monitorCheckEvery       = 256 // Check for cancellation at the first node and every N nodes
+	defaultProgressInterval = time.Second
+)
+
+// searchMonitor counts search nodes, enforces the node budget and cancellation,
+// and reports progress periodically. It is shared by all solver engines.
+// Parallel workers each use a monitor forked from it, see fork.
+type searchMonitor struct {
+	ctx      context.Context
+	budget   int
+	progress func(SolveProgress)
+	interval time.Duration
+
+	start      time.Time
+	nextReport time.Time
+	nodes      int
+	maxDepth   int
+	err        error
+	deadline   time.Time // Zero if none, see setDeadline
+
+	parent  *searchMonitor // The monitor this one was forked from, if any
+	flushed int            // Nodes already added to the parent
+	mu      sync.Mutex     // Guards a parent monitor while its forks add their nodes
+}
+
+func newSearchMonitor(ctx context.Context, opts SolveOptions) *searchMonitor {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	now := time.Now()
+	return &searchMonitor{
+		ctx:        ctx,
+		budget:     opts.NodeBudget,
+		progress:   opts.Progress,
+		interval:   interval,
+		start:      now,
+		nextReport: now.Add(interval),
+	}
+}
+
+// enter records a search node at the given depth, and reports whether the search may go on
+func (m *searchMonitor) enter(depth int) bool {
+	if m == nil {
+		return true
+	}
+	if m.err != nil {
+		return false
+	}
+
+	m.nodes++
+	m.maxDepth = max(m.maxDepth, depth)
+	if m.parent != nil {
+		// Check in with the parent as often as a standalone monitor checks the context
+		if m.nodes == 1 || m.nodes%monitorCheckEvery == 0 {
+			if err := m.parent.absorb(m.nodes-m.flushed, m.maxDepth, depth); err != nil {
+				m.err = err
+				return false
+			}
+			m.flushed = m.nodes
+			if err := m.ctx.Err(); err != nil {
+				m.err = err
+				return false
+			}
+		}
+		return true
+	}
+	if m.budget > 0 && m.nodes > m.budget {
+		m.err = ErrNodeBudgetExceeded
+		return false
+	}
+
+	if m.nodes == 1 || m.nodes%monitorCheckEvery == 0 {
+		if err := m.ctx.Err(); err != nil {
+			m.err = err
+			return false
+		}
+		if m.pastDeadline() {
+			m.err = errSearchDeadline
+			return false
+		}
+		if m.progress != nil {
+			if now := time.Now(); now.After(m.nextReport) {
+				m.nextReport = now.Add(m.interval)
+				m.progress(m.snapshot(depth))
+			}
+		}
+	}
+	return true
+}
+
+func (m *searchMonitor) snapshot(depth int) SolveProgress {
+	return SolveProgress{
+		Nodes:    m.nodes,
+		Depth:    depth,
+		MaxDepth: m.maxDepth,
+		Elapsed:  time.Since(m.start),
+	}
+}
+
+// fork returns a monitor for a parallel worker, stopped when ctx is done or the parent stops.
+// Its nodes count towards the budget of the parent, which may be exceeded by a few checks.
+func (m *searchMonitor) fork(ctx context.Context) *searchMonitor {
+	return &searchMonitor{ctx: ctx, parent: m}
+}
+
+// absorb adds the nodes explored by a fork, and returns the error stopping the search, if any
+func (m *searchMonitor) absorb(nodes, maxDepth, depth int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.nodes += nodes
+	m.maxDepth = max(m.maxDepth, maxDepth)
+	if m.budget > 0 && m.nodes > m.budget {
+		m.err = ErrNodeBudgetExceeded
+		return m.err
+	}
+	if err := m.ctx.Err(); err != nil {
+		m.err = err
+		return m.err
+	}
+	if m.pastDeadline() {
+		m.err = errSearchDeadline
+		return m.err
+	}
+	if m.progress != nil {
+		if now := time.Now(); now.After(m.nextReport) {
+			m.nextReport = now.Add(m.interval)
+			m.progress(m.snapshot(depth))
+		}
+	}
+	return nil
+}
+
+// join adds the nodes a finished fork has not reported yet
+func (m *searchMonitor) join(f *searchMonitor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nodes += f.nodes - f.flushed
+	m.maxDepth = max(m.maxDepth, f.maxDepth)
+}
+
+// setDeadline stops the search at t, checked as often as the context.
+// It may be called from visit while parallel workers are running.
+func (m *searchMonitor) setDeadline(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deadline = t
+}
+
+// pastDeadline is called with m.mu held, or from the only goroutine using a standalone monitor
+func (m *searchMonitor) pastDeadline() bool {
+	return !m.deadline.IsZero() && time.Now().After(m.deadline)
+}
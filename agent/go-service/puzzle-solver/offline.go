@@ -0,0 +1,152 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// BoardDescVersion is the version of the BoardDesc JSON written by this package.
+// Boards without a version were written before it was introduced, and are read as version 1.
+const BoardDescVersion = 1
+
+// ParseBoardDesc decodes a BoardDesc from recognition detail JSON.
+// MaaFramework may wrap the detail as {"best": {"detail": ...}}, both forms are accepted.
+// Decoding is strict: unknown fields, trailing data and newer versions are rejected, and a board
+// with a version must use the exact keys of tools/schema/puzzle.schema.json and carry its required fields.
+// Boards without a version are legacy dumps keyed by the Go field names (e.g. "W", "HueList"): they are
+// matched case-insensitively, missing fields are left zero, and they are read as version 1.
+func ParseBoardDesc(data []byte) (*BoardDesc, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("board desc: %w", err)
+	}
+	// A BoardDesc has no "best" field, so its presence tells the wrapped form apart
+	if best, ok := fields["best"]; ok {
+		var wrapped struct {
+			Detail json.RawMessage `json:"detail"`
+		}
+		if err := json.Unmarshal(best, &wrapped); err != nil {
+			return nil, fmt.Errorf("board desc: best: %w", err)
+		}
+		if len(wrapped.Detail) == 0 || string(wrapped.Detail) == "null" {
+			return nil, errors.New("board desc: best has no detail")
+		}
+		data = wrapped.Detail
+	}
+
+	var bd BoardDesc
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&bd); err != nil {
+		return nil, fmt.Errorf("board desc: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("board desc: unexpected data after the board")
+	}
+	switch {
+	case bd.Version > BoardDescVersion:
+		return nil, fmt.Errorf("board desc: unsupported version %d, newest known is %d", bd.Version, BoardDescVersion)
+	case bd.Version < 0:
+		return nil, fmt.Errorf("board desc: invalid version %d", bd.Version)
+	case bd.Version == 0:
+		bd.Version = 1
+		bd.fillThumbSlots()
+	default:
+		if err := checkSchemaKeys(data, reflect.TypeFor[BoardDesc](), "board desc"); err != nil {
+			return nil, err
+		}
+	}
+	return &bd, nil
+}
+
+// schemaRequired lists the required fields of each type, as in tools/schema/puzzle.schema.json
+var schemaRequired = map[reflect.Type][]string{
+	reflect.TypeFor[BoardDesc]():       {"version", "w", "h", "projDescList", "lockedBlockList", "puzzleList", "hueList"},
+	reflect.TypeFor[ProjDesc]():        {"xProjList", "yProjList"},
+	reflect.TypeFor[BannedBlockDesc](): {"loc", "rawLoc"},
+	reflect.TypeFor[LockedBlockDesc](): {"loc", "rawLoc", "hue"},
+	reflect.TypeFor[PuzzleDesc]():      {"blocks", "hue"},
+	reflect.TypeFor[Placement]():       {"machineX", "machineY", "rotation", "puzzleIndex"},
+}
+
+// checkSchemaKeys checks that every object in data, decoded as typ, uses the exact json tags of typ
+// and carries the fields of schemaRequired, which encoding/json alone does not enforce.
+func checkSchemaKeys(data json.RawMessage, typ reflect.Type, path string) error {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if string(data) == "null" {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for i := range typ.NumField() {
+			f := typ.Field(i)
+			key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if value, ok := fields[key]; ok {
+				if err := checkSchemaKeys(value, f.Type, path+"."+key); err != nil {
+					return err
+				}
+				delete(fields, key)
+			} else if slices.Contains(schemaRequired[typ], key) {
+				return fmt.Errorf("%s: missing field %q", path, key)
+			}
+		}
+		for key := range fields {
+			return fmt.Errorf("%s: unknown field %q", path, key)
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for i, item := range items {
+			if err := checkSchemaKeys(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fillThumbSlots assigns pages and slots in thumbnail order to boards recorded before
+// PuzzleDesc carried them, where every puzzle would otherwise claim the first slot.
+func (bd *BoardDesc) fillThumbSlots() {
+	for _, pd := range bd.PuzzleList {
+		if pd.Page != 0 || pd.Slot != 0 {
+			return
+		}
+	}
+	pageSize := PUZZLE_THUMB_MAX_ROWS * PUZZLE_THUMB_MAX_COLS
+	for i, pd := range bd.PuzzleList {
+		pd.Page, pd.Slot = i/pageSize, i%pageSize
+	}
+}
+
+// LoadBoardDesc reads a BoardDesc from a JSON file, e.g. the detail dumped by a failed PuzzleAction.
+func LoadBoardDesc(path string) (*BoardDesc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bd, err := ParseBoardDesc(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(bd.HueList) == 0 {
+		return nil, errors.New("no hues found in board desc")
+	}
+	return bd, nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const schemaFile = "../../../tools/schema/puzzle.schema.json"
+
+func TestParseBoardDesc(t *testing.T) {
+	bd, err := LoadBoardDesc(filepath.Join("testdata", "boards", "medium_5x5_2hue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bd.Version != BoardDescVersion {
+		t.Errorf("legacy board read as version %d", bd.Version)
+	}
+	data, err := json.Marshal(bd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, input := range map[string]string{
+		"plain":   string(data),
+		"wrapped": `{"all": [], "best": {"box": [0, 0, 1280, 720], "detail": ` + string(data) + `}, "filtered": []}`,
+	} {
+		got, err := ParseBoardDesc([]byte(input))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, bd) {
+			t.Errorf("%s: decoded %+v, want %+v", name, got, bd)
+		}
+	}
+
+	for name, input := range map[string]string{
+		"unknown field":  strings.Replace(string(data), `"w":`, `"width":5,"w":`, 1),
+		"trailing data":  string(data) + `{}`,
+		"newer version":  strings.Replace(string(data), `"version":1`, `"version":2`, 1),
+		"legacy key":     strings.Replace(string(data), `"w":`, `"W":`, 1),
+		"nested key":     strings.Replace(string(data), `"hue":`, `"Hue":`, 1),
+		"missing field":  strings.Replace(string(data), `"w":5,`, ``, 1),
+		"wrapped empty":  `{"best": {"box": [0, 0, 1, 1]}}`,
+		"wrapped broken": `{"best": {"detail": {"hueList": [77], "extra": true}}}`,
+		"not an object":  `[1, 2, 3]`,
+	} {
+		if _, err := ParseBoardDesc([]byte(input)); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+}
+
+// TestPuzzleSchema checks that tools/schema/puzzle.schema.json lists exactly the JSON fields of the Go types
+func TestPuzzleSchema(t *testing.T) {
+	data, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Definitions map[string]struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, typ := range map[string]reflect.Type{
+		"boardDesc":       reflect.TypeFor[BoardDesc](),
+		"projDesc":        reflect.TypeFor[ProjDesc](),
+		"bannedBlockDesc": reflect.TypeFor[BannedBlockDesc](),
+		"lockedBlockDesc": reflect.TypeFor[LockedBlockDesc](),
+		"puzzleDesc":      reflect.TypeFor[PuzzleDesc](),
+		"placement":       reflect.TypeFor[Placement](),
+	} {
+		def, ok := schema.Definitions[name]
+		if !ok {
+			t.Errorf("schema has no definition %q", name)
+			continue
+		}
+		var fields []string
+		for i := range typ.NumField() {
+			f := typ.Field(i)
+			tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if tag == "" {
+				t.Errorf("%s.%s has no json tag", typ.Name(), f.Name)
+				continue
+			}
+			fields = append(fields, tag)
+			if _, ok := def.Properties[tag]; !ok {
+				t.Errorf("schema %q lacks property %q", name, tag)
+			}
+		}
+		for prop := range def.Properties {
+			if !slices.Contains(fields, prop) {
+				t.Errorf("schema %q has property %q unknown to %s", name, prop, typ.Name())
+			}
+		}
+		for _, req := range def.Required {
+			if !slices.Contains(fields, req) {
+				t.Errorf("schema %q requires property %q unknown to %s", name, req, typ.Name())
+			}
+		}
+		if !slices.Equal(def.Required, schemaRequired[typ]) {
+			t.Errorf("schema %q requires %v, ParseBoardDesc requires %v", name, def.Required, schemaRequired[typ])
+		}
+	}
+}
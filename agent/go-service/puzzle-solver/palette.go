@@ -0,0 +1,92 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PaletteColor is one puzzle color the game is known to use
+type PaletteColor struct {
+	Name         string     `json:"name"`
+	Hue          int        `json:"hue"`          // Hue [0, 360)
+	HueTolerance int        `json:"hueTolerance"` // Largest hue difference still of this color
+	Saturation   [2]float64 `json:"saturation"`   // Saturation range [0, 1] of its blocks
+	Value        [2]float64 `json:"value"`        // Value range [0, 1] of its blocks
+}
+
+// Palette lists the known puzzle colors, loaded from paletteFile under the resource directory.
+// Saturation and value ranges describe the blocks of a piece preview, hues also apply to locked blocks.
+type Palette struct {
+	Colors []PaletteColor `json:"colors"`
+}
+
+// paletteFile is the palette path relative to the resource directory
+var paletteFile = filepath.Join("gamedata", "PuzzleSolver", "palette.json")
+
+// defaultResourceDir is the resource directory relative to the working directory of the agent
+const defaultResourceDir = "resource"
+
+// defaultPalette is used when the palette file cannot be loaded
+var defaultPalette = &Palette{Colors: []PaletteColor{
+	{Name: "green", Hue: 77, HueTolerance: 24, Saturation: [2]float64{0.5, 1}, Value: [2]float64{0.6, 1}},
+	{Name: "blue", Hue: 206, HueTolerance: 24, Saturation: [2]float64{0.5, 1}, Value: [2]float64{0.6, 1}},
+	{Name: "cyan", Hue: 169, HueTolerance: 24, Saturation: [2]float64{0.5, 1}, Value: [2]float64{0.6, 1}},
+	{Name: "orange", Hue: 33, HueTolerance: 24, Saturation: [2]float64{0.5, 1}, Value: [2]float64{0.6, 1}},
+}}
+
+// LoadPalette reads the palette of the resource directory
+func LoadPalette(resourceDir string) (*Palette, error) {
+	data, err := os.ReadFile(filepath.Join(resourceDir, paletteFile))
+	if err != nil {
+		return nil, err
+	}
+	var p Palette
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Colors) == 0 {
+		return nil, fmt.Errorf("no colors in palette %s", paletteFile)
+	}
+	for _, c := range p.Colors {
+		if c.Hue < 0 || c.Hue >= 360 || c.HueTolerance <= 0 {
+			return nil, fmt.Errorf("invalid hue of palette color %q", c.Name)
+		}
+	}
+	return &p, nil
+}
+
+// getPalette loads the palette of the resource directory, falling back to the built-in one
+func getPalette(resourceDir string) *Palette {
+	p, err := LoadPalette(resourceDir)
+	if err != nil {
+		log.Warn().Err(err).Str("resourceDir", resourceDir).Msg("Failed to load puzzle palette, using the built-in one")
+		return defaultPalette
+	}
+	return p
+}
+
+// matchHue returns the palette color closest to the hue within its tolerance, or nil
+func (p *Palette) matchHue(hue int) *PaletteColor {
+	var best *PaletteColor
+	for i := range p.Colors {
+		c := &p.Colors[i]
+		if d := diffHue(hue, c.Hue); d <= c.HueTolerance && (best == nil || d < diffHue(hue, best.Hue)) {
+			best = c
+		}
+	}
+	return best
+}
+
+// match returns the palette color of a block with the given hue, saturation and value, or nil
+func (p *Palette) match(hue int, sat, val float64) *PaletteColor {
+	c := p.matchHue(hue)
+	if c == nil || sat < c.Saturation[0] || sat > c.Saturation[1] || val < c.Value[0] || val > c.Value[1] {
+		return nil
+	}
+	return c
+}
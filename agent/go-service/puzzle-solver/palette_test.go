@@ -0,0 +1,82 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadPalette(t *testing.T) {
+	palette, err := LoadPalette(resourceDir)
+	if err != nil {
+		t.Fatalf("LoadPalette: %v", err)
+	}
+
+	// The shipped palette may add colors, e.g. for events, but keeps the built-in ones
+	shipped := make(map[string]PaletteColor, len(palette.Colors))
+	for _, c := range palette.Colors {
+		if _, ok := shipped[c.Name]; ok {
+			t.Errorf("color %q listed twice", c.Name)
+		}
+		shipped[c.Name] = c
+	}
+	for _, want := range defaultPalette.Colors {
+		if got, ok := shipped[want.Name]; !ok {
+			t.Errorf("built-in color %q missing from the shipped palette", want.Name)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("color %q is %+v in the shipped palette, %+v built in", want.Name, got, want)
+		}
+	}
+
+	for _, c := range palette.Colors {
+		if c.Name == "" {
+			t.Errorf("color %+v has no name", c)
+		}
+		if c.Hue < 0 || c.Hue >= 360 || c.HueTolerance <= 0 || c.HueTolerance >= 180 {
+			t.Errorf("color %q: hue %d, tolerance %d out of range", c.Name, c.Hue, c.HueTolerance)
+		}
+		for _, r := range [][2]float64{c.Saturation, c.Value} {
+			if r[0] < 0 || r[1] > 1 || r[0] > r[1] {
+				t.Errorf("color %q: range %v is not within [0, 1]", c.Name, r)
+			}
+		}
+	}
+
+	if _, err := LoadPalette(t.TempDir()); err == nil {
+		t.Error("LoadPalette of a directory without palette succeeded")
+	}
+}
+
+func TestPaletteMatch(t *testing.T) {
+	cases := []struct {
+		hue      int
+		sat, val float64
+		want     string
+	}{
+		{77, 0.8, 0.9, "green"},
+		{216, 0.8, 0.9, "blue"},
+		{160, 0.8, 0.9, "cyan"},
+		{5, 0.8, 0.9, ""}, // Red is out of the orange tolerance
+		{20, 0.8, 0.9, "orange"},
+		{300, 0.8, 0.9, ""}, // Magenta is not in the palette
+		{77, 0.2, 0.9, ""},  // Too pale
+	}
+	for _, c := range cases {
+		got := ""
+		if pc := defaultPalette.match(c.hue, c.sat, c.val); pc != nil {
+			got = pc.Name
+		}
+		if got != c.want {
+			t.Errorf("match(%d, %.1f, %.1f) = %q, want %q", c.hue, c.sat, c.val, got, c.want)
+		}
+	}
+}
+
+func TestGetPossibleHues(t *testing.T) {
+	puzzles := []*PuzzleDesc{{Hue: 210}, {Hue: 75}, {Hue: 202}, {Hue: 79}, {Hue: 300}, {Hue: 304}}
+	got := getPossibleHues(puzzles, defaultPalette)
+	want := []int{206, 77, 302}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getPossibleHues = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// branch is a placement of the first puzzle in order, searched by one worker
+type branch struct {
+	deriv       *Puzzle
+	x, y, first int
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	solutions [][]Placement
+	done      chan struct{}
+}
+
+// solveParallel is like solveWith, but splits the search at the placements of the first puzzle in order
+// and searches them on up to workers goroutines, each with its own copy of the board.
+// Branches are shuffled by seed, 0 keeps the order of solveWith. Each branch collects up to limit solutions,
+// and those are passed to visit in branch order, so the result only depends on the seed.
+// Once a branch has limit solutions, the branches after it are stopped. The branches before it still
+// run to the end: stopping every worker at the first solution found would make the result depend on
+// scheduling, so the search gives up some speed for returning what the sequential search would.
+func (b *Board) solveParallel(puzzles []*Puzzle, m *searchMonitor, workers int, seed int64, limit int, visit func([]Placement) bool) bool {
+	s := newBacktracker(b, puzzles, m, nil)
+	if !m.enter(0) {
+		return false
+	}
+	if len(puzzles) == 0 {
+		return visit([]Placement{})
+	}
+
+	var branches []*branch
+	s.candidates(0, func(deriv *Puzzle, x, y, first int) bool {
+		br := &branch{deriv: deriv, x: x, y: y, first: first, done: make(chan struct{})}
+		br.ctx, br.cancel = context.WithCancel(m.ctx)
+		branches = append(branches, br)
+		return false
+	})
+	if seed != 0 {
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(branches), func(i, j int) { branches[i], branches[j] = branches[j], branches[i] })
+	}
+
+	var next atomic.Int64
+	var stopAfter atomic.Int64 // Index of the first branch that reached limit
+	stopAfter.Store(int64(len(branches)))
+	stopFrom := func(i int) {
+		for {
+			cur := stopAfter.Load()
+			if int64(i) >= cur {
+				return
+			}
+			if stopAfter.CompareAndSwap(cur, int64(i)) {
+				break
+			}
+		}
+		for _, br := range branches[i+1:] {
+			br.cancel()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range min(workers, len(branches)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(branches) {
+					return
+				}
+				br := branches[i]
+				if int64(i) <= stopAfter.Load() && br.ctx.Err() == nil {
+					fm := m.fork(br.ctx)
+					w := s.fork(fm, func(p []Placement) bool {
+						br.solutions = append(br.solutions, p)
+						if len(br.solutions) >= limit {
+							stopFrom(i)
+							return true
+						}
+						return false
+					})
+					w.apply(0, br.deriv, br.x, br.y, br.first)
+					w.search(1)
+					m.join(fm)
+				}
+				close(br.done)
+			}
+		}()
+	}
+	defer func() {
+		for _, br := range branches {
+			br.cancel()
+		}
+		wg.Wait()
+	}()
+
+	for _, br := range branches {
+		<-br.done
+		for _, p := range br.solutions {
+			if visit(p) {
+				return true
+			}
+		}
+	}
+	if err := m.ctx.Err(); err != nil {
+		m.mu.Lock()
+		if m.err == nil {
+			m.err = err
+		}
+		m.mu.Unlock()
+	}
+	return false
+}
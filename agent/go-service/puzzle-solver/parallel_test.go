@@ -0,0 +1,101 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSolveParallel(t *testing.T) {
+	corpus := append(generatedCorpus("medium", 2, 30), generatedCorpus("large", 2, 10)...)
+	for i, bd := range corpus {
+		want, err := SolveWithOptions(context.Background(), bd, SolveOptions{Engine: EngineBacktrack})
+		if err != nil {
+			t.Fatalf("#%d sequentially: %v", i, err)
+		}
+		got, err := SolveWithOptions(context.Background(), bd, SolveOptions{Engine: EngineBacktrack, Workers: 4})
+		if err != nil {
+			t.Fatalf("#%d in parallel: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d: parallel search without a seed found %v, sequential %v", i, got, want)
+		}
+
+		opts := SolveOptions{Engine: EngineBacktrack, Workers: 4, Seed: 42}
+		a, err := SolveWithOptions(context.Background(), bd, opts)
+		if err != nil {
+			t.Fatalf("#%d with a seed: %v", i, err)
+		}
+		if err := VerifyPlacements(bd, a); err != nil {
+			t.Fatalf("#%d with a seed: %v", i, err)
+		}
+		b, _ := SolveWithOptions(context.Background(), bd, opts)
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("#%d: the same seed found %v, then %v", i, a, b)
+		}
+	}
+}
+
+func TestSolveAllParallel(t *testing.T) {
+	for i, bd := range generatedCorpus("medium", 3, 20) {
+		opts := SolveOptions{Engine: EngineBacktrack, MaxSolutions: 8, ExtraSearchTime: time.Minute}
+		want, err := SolveAll(context.Background(), bd, opts)
+		if err != nil {
+			t.Fatalf("#%d sequentially: %v", i, err)
+		}
+		opts.Workers = 4
+		got, err := SolveAll(context.Background(), bd, opts)
+		if err != nil {
+			t.Fatalf("#%d in parallel: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d: parallel search found %d solutions, sequential %d", i, len(got.Solutions), len(want.Solutions))
+		}
+	}
+}
+
+func TestSolveParallelStops(t *testing.T) {
+	bd := generatedCorpus("hard", 2, 1)[0]
+	_, err := SolveWithOptions(context.Background(), bd, SolveOptions{Engine: EngineBacktrack, Workers: 4, NodeBudget: 100})
+	if !errors.Is(err, ErrNodeBudgetExceeded) {
+		t.Errorf("with a node budget: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = SolveWithOptions(ctx, bd, SolveOptions{Engine: EngineBacktrack, Workers: 4})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("with a canceled context: %v", err)
+	}
+}
+
+// BenchmarkParallelBacktrack reports the speedup of each worker count over a single worker
+func BenchmarkParallelBacktrack(b *testing.B) {
+	corpus := generatedCorpus("hard", 1, 10)
+	var baseline time.Duration // Time per corpus with one worker
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			opts := SolveOptions{Engine: EngineBacktrack, Workers: workers}
+			n := 0
+			for b.Loop() {
+				for _, bd := range corpus {
+					if _, err := SolveWithOptions(context.Background(), bd, opts); err != nil {
+						b.Fatal(err)
+					}
+				}
+				n++
+			}
+			perCorpus := b.Elapsed() / time.Duration(n)
+			if workers == 1 {
+				baseline = perCorpus
+			}
+			if baseline > 0 {
+				b.ReportMetric(float64(baseline)/float64(perCorpus), "speedup")
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+)
+
+// projReading is the number of bars read from a projection figure, with how far it can be trusted
+type projReading struct {
+	Count      int
+	Confidence float64 // In [0, 1]
+	Agreed     bool    // Whether both readers of getProjFigureNumber found Count
+}
+
+// projComponent is a connected area of the target hue in a projection figure. Offsets are in pixels
+// from the inner edge of the figure, the one facing the board, along which the bars are stacked.
+type projComponent struct {
+	area       int
+	near, far  int // Nearest and farthest offset covered
+	centerDist float64
+}
+
+// getProjFigureNumber reads a projection figure both by counting its connected components and by
+// scanning how far it reaches out (scanProjFigureNumber). If they agree, the count is trusted from
+// PROJ_DISAGREE_MAX_CONFIDENCE up to 1 the more the components looked like clean bars; otherwise
+// the scanned count is kept, trusted from PROJ_DISAGREE_MAX_CONFIDENCE down to 0 the more they did.
+func getProjFigureNumber(img image.Image, ltX, ltY int, axis string, targetHue int) projReading {
+	scanned := scanProjFigureNumber(img, ltX, ltY, axis, targetHue)
+	counted := countProjFigureComponents(img, ltX, ltY, axis, targetHue)
+	if counted.Count == scanned {
+		confidence := PROJ_DISAGREE_MAX_CONFIDENCE + (1-PROJ_DISAGREE_MAX_CONFIDENCE)*counted.Confidence
+		return projReading{Count: scanned, Confidence: confidence, Agreed: true}
+	}
+
+	reading := projReading{Count: scanned, Confidence: PROJ_DISAGREE_MAX_CONFIDENCE * (1 - counted.Confidence)}
+	log.Warn().
+		Str("axis", axis).
+		Int("ltX", ltX).Int("ltY", ltY).
+		Int("hue", targetHue).
+		Int("scanned", scanned).
+		Int("components", counted.Count).
+		Float64("componentConfidence", counted.Confidence).
+		Float64("confidence", reading.Confidence).
+		Msg("Projection figure readers disagree, keeping the scanned count")
+	return reading
+}
+
+// getProjFigureRect returns the area of the projection figure whose top left corner is (ltX, ltY),
+// and whether its inner edge is the bottom one (X axis) rather than the right one (Y axis)
+func getProjFigureRect(ltX, ltY int, axis string) (image.Rectangle, bool) {
+	if axis == "X" {
+		return image.Rect(ltX, ltY, ltX+int(BOARD_BLOCK_W), ltY+int(PROJ_X_FIGURE_H)), true
+	}
+	return image.Rect(ltX, ltY, ltX+int(PROJ_Y_FIGURE_W), ltY+int(BOARD_BLOCK_H)), false
+}
+
+// countProjFigureComponents segments a projection figure into 4-connected components of the target hue,
+// and counts each component as a bar, which does not depend on where the bars are exactly.
+// Its confidence drops for components that do not look like evenly stacked bars:
+// thicker than one bar, e.g. bars merged by anti-aliasing, or off the bar pitch from their neighbour.
+func countProjFigureComponents(img image.Image, ltX, ltY int, axis string, targetHue int) projReading {
+	rect, bottomInner := getProjFigureRect(ltX, ltY, axis)
+	rect = rect.Intersect(img.Bounds())
+	w, h := rect.Dx(), rect.Dy()
+	if w <= 0 || h <= 0 {
+		return projReading{}
+	}
+
+	mask := make([]bool, w*h)
+	for y := range h {
+		for x := range w {
+			_, s, v := getPixelHSV(img, rect.Min.X+x, rect.Min.Y+y, targetHue, PUZZLE_HUE_DIFF_GRT)
+			mask[y*w+x] = s > PROJ_COLOR_SAT_GRT && v > PROJ_COLOR_VAL_GRT
+		}
+	}
+	// Offset of a pixel from the inner edge, starting at 1 like scanProjFigureNumber
+	offset := func(x, y int) int {
+		if bottomInner {
+			return h - y
+		}
+		return w - x
+	}
+
+	var components []projComponent
+	seen := make([]bool, w*h)
+	queue := make([]int, 0, w*h)
+	for start := range mask {
+		if !mask[start] || seen[start] {
+			continue
+		}
+		c := projComponent{near: math.MaxInt, far: math.MinInt}
+		seen[start] = true
+		queue = append(queue[:0], start)
+		for len(queue) > 0 {
+			i := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			x, y := i%w, i/w
+			c.area++
+			o := offset(x, y)
+			c.near, c.far = min(c.near, o), max(c.far, o)
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n[0] < 0 || n[0] >= w || n[1] < 0 || n[1] >= h {
+					continue
+				}
+				if j := n[1]*w + n[0]; mask[j] && !seen[j] {
+					seen[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+		if c.area >= PROJ_COMPONENT_MIN_AREA {
+			c.centerDist = float64(c.near+c.far) / 2
+			components = append(components, c)
+		}
+	}
+	if len(components) == 0 {
+		return projReading{Count: 0, Confidence: 1}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].centerDist < components[j].centerDist })
+
+	// Each bar should be at most one pitch thick, and one pitch away from the previous one,
+	// the first one being centered half a pitch past the initial gap
+	pitch := PROJ_EACH_GAP
+	prevCenter := PROJ_INIT_GAP - pitch/2
+	total := 0.0
+	for _, c := range components {
+		thickness := float64(c.far - c.near + 1)
+		thicknessScore := 1 - max(0, thickness-pitch)/pitch
+		spacingScore := 1 - math.Abs(c.centerDist-prevCenter-pitch)/pitch
+		total += max(0, min(thicknessScore, spacingScore))
+		prevCenter = c.centerDist
+	}
+	return projReading{Count: len(components), Confidence: total / float64(len(components))}
+}
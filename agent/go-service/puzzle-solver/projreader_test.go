@@ -0,0 +1,101 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// drawProjBars draws n bars of the hue into the projection figure at (ltX, ltY),
+// shifted outwards by shift pixels and separated by gap pixels
+func drawProjBars(img *image.RGBA, ltX, ltY int, axis string, hue, n, shift, gap int) {
+	rect, bottomInner := getProjFigureRect(ltX, ltY, axis)
+	c := hsvToRGB(float64(hue), 0.8, 0.9)
+	for k := 1; k <= n; k++ {
+		near := int(math.Ceil(PROJ_INIT_GAP+float64(k-1)*PROJ_EACH_GAP)) + gap + shift
+		far := int(PROJ_INIT_GAP+float64(k)*PROJ_EACH_GAP) + shift
+		for o := near; o <= far; o++ {
+			if bottomInner {
+				for x := rect.Min.X + rect.Dx()/5; x < rect.Max.X-rect.Dx()/5; x++ {
+					img.Set(x, rect.Max.Y-o, c)
+				}
+			} else {
+				for y := rect.Min.Y + rect.Dy()/5; y < rect.Max.Y-rect.Dy()/5; y++ {
+					img.Set(rect.Max.X-o, y, c)
+				}
+			}
+		}
+	}
+}
+
+func TestGetProjFigureNumber(t *testing.T) {
+	const hue = 206
+	for _, axis := range []string{"X", "Y"} {
+		for n := range 7 {
+			img := newFilledImage(color.RGBA{20, 20, 20, 255})
+			drawProjBars(img, 100, 100, axis, hue, n, 0, 2)
+			r := getProjFigureNumber(img, 100, 100, axis, hue)
+			if r.Count != n || r.Confidence < 0.9 {
+				t.Errorf("%s axis, %d clean bars: read %+v", axis, n, r)
+			}
+
+			// Another hue is not counted
+			if r := countProjFigureComponents(img, 100, 100, axis, 77); r.Count != 0 {
+				t.Errorf("%s axis, %d bars of another hue: counted %d", axis, n, r.Count)
+			}
+		}
+	}
+
+	// Bars shifted by UI scaling still make the same components, while the scan overshoots
+	img := newFilledImage(color.RGBA{20, 20, 20, 255})
+	drawProjBars(img, 100, 100, "X", hue, 3, 6, 2)
+	if r := countProjFigureComponents(img, 100, 100, "X", hue); r.Count != 3 {
+		t.Errorf("shifted bars: counted %+v", r)
+	}
+	if got := scanProjFigureNumber(img, 100, 100, "X", hue); got == 3 {
+		t.Errorf("shifted bars: scanned %d, the scan was expected to miss them", got)
+	}
+	if r := getProjFigureNumber(img, 100, 100, "X", hue); r.Confidence >= 0.5 {
+		t.Errorf("shifted bars: disagreeing readers trusted with %+v", r)
+	}
+
+	// Bars merged by anti-aliasing make one thick component, which is not trusted
+	img = newFilledImage(color.RGBA{20, 20, 20, 255})
+	drawProjBars(img, 100, 100, "Y", hue, 4, 0, 0)
+	counted := countProjFigureComponents(img, 100, 100, "Y", hue)
+	if counted.Count != 1 || counted.Confidence > 0.1 {
+		t.Errorf("merged bars: counted %+v", counted)
+	}
+	if r := getProjFigureNumber(img, 100, 100, "Y", hue); r.Count != 4 || r.Confidence < 0.45 {
+		t.Errorf("merged bars: read %+v, want the scanned 4", r)
+	}
+}
+
+func TestGetProjDescConfidence(t *testing.T) {
+	const hue = 206
+	xLtX, xLtY := int(BOARD_CENTER_BLOCK_LT_X), int(BOARD_CENTER_BLOCK_LT_Y-PROJ_X_FIGURE_H)
+	yLtX, yLtY := int(BOARD_CENTER_BLOCK_LT_X-PROJ_Y_FIGURE_W), int(BOARD_CENTER_BLOCK_LT_Y)
+
+	img := newFilledImage(color.RGBA{20, 20, 20, 255})
+	drawProjBars(img, xLtX, xLtY, "X", hue, 1, 0, 2)
+	drawProjBars(img, yLtX, yLtY, "Y", hue, 1, 0, 2)
+	if pd, c := getProjDesc(img, [2]int{1, 1}, hue); pd.XProjList[0] != 1 || pd.YProjList[0] != 1 || c != 1 {
+		t.Errorf("clean bars: read %+v with confidence %v", pd, c)
+	}
+
+	// A single disagreeing figure caps the board confidence below the gate, even when the components
+	// looked nothing like bars
+	for name, draw := range map[string]func(img *image.RGBA){
+		"shifted bars": func(img *image.RGBA) { drawProjBars(img, xLtX, xLtY, "X", hue, 3, 6, 2) },
+		"merged bars":  func(img *image.RGBA) { drawProjBars(img, xLtX, xLtY, "X", hue, 3, 0, 0) },
+	} {
+		img = newFilledImage(color.RGBA{20, 20, 20, 255})
+		draw(img)
+		drawProjBars(img, yLtX, yLtY, "Y", hue, 3, 0, 2)
+		if _, c := getProjDesc(img, [2]int{1, 1}, hue); c > PROJ_DISAGREE_MAX_CONFIDENCE || c >= PUZZLE_MIN_CONFIDENCE {
+			t.Errorf("%s: confidence %v would pass the gate", name, c)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import "slices"
+
+// candidate is one placement of a puzzle derivative that fits the initial board
+type candidate struct {
+	deriv     *Puzzle
+	x, y      int
+	first     int      // Row-major index of the first covered cell
+	cells     [][2]int // Absolute board cells covered
+	colCounts [][2]int // (x, count) pairs of covered cells per column
+	rowCounts [][2]int // (y, count) pairs of covered cells per row
+}
+
+// propagator searches placements most-constrained-first and prunes
+// as soon as a projection can no longer be met by the remaining puzzles.
+type propagator struct {
+	board     *Board
+	puzzles   []*Puzzle
+	cands     [][]candidate // Static candidates per puzzle
+	placed    []bool
+	solution  []Placement
+	visit     func([]Placement) bool
+	monitor   *searchMonitor
+	depth     int   // Number of placed puzzles
+	remaining []int // Remaining block count of unplaced puzzles per hue
+
+	// Identical puzzles are chained in index order, and their placements must keep that order
+	identicalPrev []int
+	identicalNext []int
+	firstCells    []int
+
+	// Scratch buffers reused by each search node
+	coverX [][]int
+	coverY [][]int
+}
+
+// newCandidate builds the candidate of deriv anchored at (cx, cy), or returns false if it does not fit
+func (b *Board) newCandidate(deriv *Puzzle, cx, cy int) (candidate, bool) {
+	c := candidate{
+		deriv: deriv,
+		x:     cx,
+		y:     cy,
+		first: deriv.getFirstCell(cx, cy, b.XSize),
+		cells: make([][2]int, 0, len(deriv.Blocks)),
+	}
+	for _, block := range deriv.Blocks {
+		nx, ny := cx+block[0], cy+block[1]
+		if nx < 0 || nx >= b.XSize || ny < 0 || ny >= b.YSize {
+			return candidate{}, false
+		}
+		if b.Grid[ny][nx] != -1 {
+			return candidate{}, false
+		}
+		c.cells = append(c.cells, [2]int{nx, ny})
+		c.colCounts = addLineCount(c.colCounts, nx)
+		c.rowCounts = addLineCount(c.rowCounts, ny)
+	}
+
+	// Reject placements that already overflow a projection
+	for _, lc := range c.colCounts {
+		if b.CurrXCounts[deriv.Color][lc[0]]+lc[1] > b.XProj[deriv.Color][lc[0]] {
+			return candidate{}, false
+		}
+	}
+	for _, lc := range c.rowCounts {
+		if b.CurrYCounts[deriv.Color][lc[0]]+lc[1] > b.YProj[deriv.Color][lc[0]] {
+			return candidate{}, false
+		}
+	}
+	return c, true
+}
+
+func addLineCount(counts [][2]int, line int) [][2]int {
+	for i := range counts {
+		if counts[i][0] == line {
+			counts[i][1]++
+			return counts
+		}
+	}
+	return append(counts, [2]int{line, 1})
+}
+
+// fits reports whether the candidate still fits the current board state
+func (s *propagator) fits(c *candidate) bool {
+	b := s.board
+	for _, cell := range c.cells {
+		if b.Grid[cell[1]][cell[0]] != -1 {
+			return false
+		}
+	}
+	color := c.deriv.Color
+	for _, lc := range c.colCounts {
+		if b.CurrXCounts[color][lc[0]]+lc[1] > b.XProj[color][lc[0]] {
+			return false
+		}
+	}
+	for _, lc := range c.rowCounts {
+		if b.CurrYCounts[color][lc[0]]+lc[1] > b.YProj[color][lc[0]] {
+			return false
+		}
+	}
+	return true
+}
+
+// orderBounds returns the exclusive range of first cells allowed for the puzzle,
+// given the placed puzzles identical to it
+func (s *propagator) orderBounds(i int) (int, int) {
+	lower, upper := -1, s.board.XSize*s.board.YSize
+	for p := s.identicalPrev[i]; p >= 0; p = s.identicalPrev[p] {
+		if s.placed[p] {
+			lower = s.firstCells[p]
+			break
+		}
+	}
+	for n := s.identicalNext[i]; n >= 0; n = s.identicalNext[n] {
+		if s.placed[n] {
+			upper = s.firstCells[n]
+			break
+		}
+	}
+	return lower, upper
+}
+
+// isComplete reports whether every projection is met exactly
+func (s *propagator) isComplete() bool {
+	b := s.board
+	for h := 0; h < b.K; h++ {
+		for x := 0; x < b.XSize; x++ {
+			if b.CurrXCounts[h][x] != b.XProj[h][x] {
+				return false
+			}
+		}
+		for y := 0; y < b.YSize; y++ {
+			if b.CurrYCounts[h][y] != b.YProj[h][y] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isFeasible checks that the remaining puzzles can still satisfy every projection
+func (s *propagator) isFeasible() bool {
+	b := s.board
+	for h := 0; h < b.K; h++ {
+		needX, needY := 0, 0
+		for x := 0; x < b.XSize; x++ {
+			need := b.XProj[h][x] - b.CurrXCounts[h][x]
+			if need > s.coverX[h][x] {
+				return false
+			}
+			needX += need
+		}
+		for y := 0; y < b.YSize; y++ {
+			need := b.YProj[h][y] - b.CurrYCounts[h][y]
+			if need > s.coverY[h][y] {
+				return false
+			}
+			needY += need
+		}
+		// Every remaining block must land on a cell the projections still ask for
+		if needX != s.remaining[h] || needY != s.remaining[h] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *propagator) search() bool {
+	if !s.monitor.enter(s.depth) {
+		return false
+	}
+	b := s.board
+	for h := 0; h < b.K; h++ {
+		clear(s.coverX[h])
+		clear(s.coverY[h])
+	}
+
+	// 1. Collect valid candidates of every unplaced puzzle, and pick the most constrained one
+	bestIdx := -1
+	var bestValid []int
+	maxX := make([]int, b.XSize)
+	maxY := make([]int, b.YSize)
+	for i, pz := range s.puzzles {
+		if s.placed[i] {
+			continue
+		}
+		clear(maxX)
+		clear(maxY)
+		lower, upper := s.orderBounds(i)
+		valid := make([]int, 0, len(s.cands[i]))
+		for j := range s.cands[i] {
+			c := &s.cands[i][j]
+			if c.first <= lower || c.first >= upper || !s.fits(c) {
+				continue
+			}
+			valid = append(valid, j)
+			for _, lc := range c.colCounts {
+				maxX[lc[0]] = max(maxX[lc[0]], lc[1])
+			}
+			for _, lc := range c.rowCounts {
+				maxY[lc[0]] = max(maxY[lc[0]], lc[1])
+			}
+		}
+		if len(valid) == 0 {
+			return false
+		}
+
+		// The most this puzzle can still add to each line of its hue
+		for x, v := range maxX {
+			s.coverX[pz.Color][x] += v
+		}
+		for y, v := range maxY {
+			s.coverY[pz.Color][y] += v
+		}
+
+		// Prefer fewer candidates, then larger puzzles
+		if bestIdx < 0 || len(valid) < len(bestValid) ||
+			(len(valid) == len(bestValid) && len(pz.Blocks) > len(s.puzzles[bestIdx].Blocks)) {
+			bestIdx = i
+			bestValid = valid
+		}
+	}
+
+	if bestIdx < 0 {
+		if !s.isComplete() {
+			return false
+		}
+		return s.visit(slices.Clone(s.solution))
+	}
+
+	// 2. Prune if some projection can no longer be met
+	if !s.isFeasible() {
+		return false
+	}
+
+	// 3. Branch on the candidates of the most constrained puzzle
+	pz := s.puzzles[bestIdx]
+	s.placed[bestIdx] = true
+	s.remaining[pz.Color] -= len(pz.Blocks)
+	s.depth++
+	for _, j := range bestValid {
+		c := &s.cands[bestIdx][j]
+		b.place(c.deriv, c.x, c.y)
+		s.firstCells[bestIdx] = c.first
+		s.solution[bestIdx] = Placement{
+			MachineX:    c.x,
+			MachineY:    c.y,
+			Rotation:    c.deriv.Rotation,
+			PuzzleIndex: bestIdx,
+		}
+
+		if s.search() {
+			return true
+		}
+
+		b.remove(c.deriv, c.x, c.y)
+	}
+	s.depth--
+	s.remaining[pz.Color] += len(pz.Blocks)
+	s.placed[bestIdx] = false
+	return false
+}
+
+// solvePropagate solves the board with constraint propagation.
+// Unlike solveWith, it requires every projection to be met exactly.
+// Each solution is passed to visit, which returns true to stop the search.
+// It reports whether the search was stopped by visit.
+func (b *Board) solvePropagate(puzzles []*Puzzle, m *searchMonitor, visit func([]Placement) bool) bool {
+	s := &propagator{
+		board:     b,
+		puzzles:   puzzles,
+		cands:     make([][]candidate, len(puzzles)),
+		placed:    make([]bool, len(puzzles)),
+		solution:  make([]Placement, len(puzzles)),
+		visit:     visit,
+		monitor:   m,
+		remaining: make([]int, b.K),
+		coverX:    make([][]int, b.K),
+		coverY:    make([][]int, b.K),
+
+		identicalPrev: getIdenticalPrev(puzzles),
+		identicalNext: make([]int, len(puzzles)),
+		firstCells:    make([]int, len(puzzles)),
+	}
+	for i := range s.identicalNext {
+		s.identicalNext[i] = -1
+	}
+	for i, p := range s.identicalPrev {
+		if p >= 0 {
+			s.identicalNext[p] = i
+		}
+	}
+	for h := 0; h < b.K; h++ {
+		s.coverX[h] = make([]int, b.XSize)
+		s.coverY[h] = make([]int, b.YSize)
+	}
+
+	for i, pz := range puzzles {
+		if pz.Color < 0 || pz.Color >= b.K {
+			return false
+		}
+		s.remaining[pz.Color] += len(pz.Blocks)
+		for _, deriv := range pz.getUniqueDerivatives() {
+			for y := 0; y < b.YSize; y++ {
+				for x := 0; x < b.XSize; x++ {
+					if c, ok := b.newCandidate(deriv, x, y); ok {
+						s.cands[i] = append(s.cands[i], c)
+					}
+				}
+			}
+		}
+	}
+
+	return s.search()
+}
@@ -3,6 +3,7 @@ package puzzle
 
 import (
 	"encoding/json"
+	"errors"
 	"image"
 	"math"
 	"time"
@@ -11,59 +12,84 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// The JSON forms of the types below are described by tools/schema/puzzle.schema.json,
+// keep them in sync and bump BoardDescVersion on incompatible changes.
+
 type ProjDesc struct {
-	XProjList []int
-	YProjList []int
+	XProjList []int `json:"xProjList"`
+	YProjList []int `json:"yProjList"`
 }
 
 type BannedBlockDesc struct {
-	Loc    [2]int
-	RawLoc [2]int
+	Loc    [2]int `json:"loc"`
+	RawLoc [2]int `json:"rawLoc"`
 }
 
 type LockedBlockDesc struct {
-	Loc    [2]int
-	RawLoc [2]int
-	Hue    int
+	Loc    [2]int `json:"loc"`
+	RawLoc [2]int `json:"rawLoc"`
+	Hue    int    `json:"hue"`
 }
 
 type PuzzleDesc struct {
-	Blocks [][2]int
-	Hue    int
+	Blocks [][2]int `json:"blocks"`
+	Hue    int      `json:"hue"`
+	Page   int      `json:"page"` // Page of the thumbnail panel showing this puzzle
+	Slot   int      `json:"slot"` // Thumbnail index within the page, in standard grid order
 }
 
 type BoardDesc struct {
-	W               int
-	H               int
-	ProjDescList    []ProjDesc
-	BannedBlockList []*BannedBlockDesc
-	LockedBlockList [][]*LockedBlockDesc
-	PuzzleList      []*PuzzleDesc
-	HueList         []int
+	Version         int                  `json:"version"` // See BoardDescVersion
+	Tab             int                  `json:"tab"`     // Index of the board tab, see TAB_X_LIST
+	W               int                  `json:"w"`
+	H               int                  `json:"h"`
+	ProjDescList    []ProjDesc           `json:"projDescList"`
+	BannedBlockList []*BannedBlockDesc   `json:"bannedBlockList"`
+	LockedBlockList [][]*LockedBlockDesc `json:"lockedBlockList"`
+	PuzzleList      []*PuzzleDesc        `json:"puzzleList"`
+	HueList         []int                `json:"hueList"`
+	Confidence      float64              `json:"confidence"` // Self-consistency score of ValidateBoardDesc, capped at PROJ_DISAGREE_MAX_CONFIDENCE or below by disagreeing projection readers
 }
 
 type Recognition struct{}
 
-// Known color hues: 77 (green), 206(blue), 169(cyan), 33(orange)
-
-func getPossibleHues(puzzles []*PuzzleDesc) []int {
-	hues := make([]int, 0, len(puzzles))
+// getPossibleHues returns one hue per color among the puzzles, in order of first appearance.
+// Hues are grouped by the palette color they match (see paletteFile),
+// hues matching no palette color are clustered by PUZZLE_HUE_DIFF_GRT and reported.
+func getPossibleHues(puzzles []*PuzzleDesc, palette *Palette) []int {
+	var order []string
+	groups := make(map[string][]int)
+	var unknown []int
 	for _, p := range puzzles {
-		hues = append(hues, p.Hue)
+		c := palette.matchHue(p.Hue)
+		if c == nil {
+			unknown = append(unknown, p.Hue)
+			continue
+		}
+		if _, ok := groups[c.Name]; !ok {
+			order = append(order, c.Name)
+		}
+		groups[c.Name] = append(groups[c.Name], p.Hue)
 	}
-	clusters := clusterHues(hues, PUZZLE_HUE_DIFF_GRT)
 
-	results := make([]int, 0, len(clusters))
-	for _, members := range clusters {
-		if len(members) == 0 {
-			continue
+	results := make([]int, 0, len(order))
+	for _, name := range order {
+		results = append(results, meanHue(groups[name]))
+	}
+	if len(unknown) > 0 {
+		clusters := clusterHues(unknown, PUZZLE_HUE_DIFF_GRT)
+		for _, h := range unknown {
+			if members, ok := clusters[h]; ok {
+				log.Warn().Int("hue", h).Ints("members", members).Msg("Puzzle color is not in the palette, treating it as a new color")
+				results = append(results, meanHue(members))
+				delete(clusters, h)
+			}
 		}
-		results = append(results, meanHue(members))
 	}
 	return results
 }
 
-func getPossibleBoardSize(ctx *maa.Context, img image.Image) [2]int {
+func getPossibleBoardSize(r recognizer, img image.Image) [2]int {
 	maxExtent := BOARD_MAX_EXTENT_ONE_SIDE
 	biasFactor := 0.075
 	cropFactor := 0.75 // important
@@ -73,7 +99,7 @@ func getPossibleBoardSize(ctx *maa.Context, img image.Image) [2]int {
 	imgSvgb := getSVGBImage(img)
 
 	// 1. Determine H (using XProj figures at the top)
-	xMatches := matchTemplateAll(ctx, imgSvgb, "PuzzleSolver/ProjX_SVGB.png", []int{
+	xMatches := r.matchTemplateAll(imgSvgb, "PuzzleSolver/ProjX_SVGB.png", []int{
 		int(BOARD_X_LOWER_BOUND),
 		int(BOARD_Y_LOWER_BOUND),
 		int(BOARD_X_UPPER_BOUND - BOARD_X_LOWER_BOUND),
@@ -108,7 +134,7 @@ func getPossibleBoardSize(ctx *maa.Context, img image.Image) [2]int {
 	}
 
 	// 2. Determine W (using YProj figures at the left)
-	yMatches := matchTemplateAll(ctx, imgSvgb, "PuzzleSolver/ProjY_SVGB.png", []int{
+	yMatches := r.matchTemplateAll(imgSvgb, "PuzzleSolver/ProjY_SVGB.png", []int{
 		int(BOARD_X_LOWER_BOUND),
 		int(BOARD_Y_LOWER_BOUND),
 		int(BOARD_X_UPPER_BOUND-BOARD_X_LOWER_BOUND) / 2,
@@ -163,7 +189,9 @@ func convertBlockLtToBannedBlockDesc(boardW, boardH int, blocks [][2]int) []*Ban
 	return gridBlocks
 }
 
-func getProjDesc(ctx *maa.Context, img image.Image, boardSize [2]int, targetHue int) *ProjDesc {
+// getProjDesc reads the projection figures of a hue, along with the cap its readings put on the board confidence:
+// 1 if both readers of every figure agree, otherwise the confidence of the least trusted disagreeing reading
+func getProjDesc(img image.Image, boardSize [2]int, targetHue int) (*ProjDesc, float64) {
 	// First, determine the board dimensions using template matching analysis
 	W, H := boardSize[0], boardSize[1]
 
@@ -175,6 +203,7 @@ func getProjDesc(ctx *maa.Context, img image.Image, boardSize [2]int, targetHue
 	distY := float64(H-1) / 2.0
 	projFigY := BOARD_CENTER_BLOCK_LT_Y - distY*BOARD_BLOCK_H - PROJ_X_FIGURE_H
 
+	confidence := 1.0
 	finalXProjList := make([]int, W)
 	for gridX := range W {
 		// Calculate precise X-coordinate for each column's projection figure
@@ -182,7 +211,11 @@ func getProjDesc(ctx *maa.Context, img image.Image, boardSize [2]int, targetHue
 		gridIdxRel := float64(gridX) - float64(W-1)/2.0
 		projFigX := BOARD_CENTER_BLOCK_LT_X + gridIdxRel*BOARD_BLOCK_W
 
-		finalXProjList[gridX] = getProjFigureNumber(ctx, img, int(projFigX), int(projFigY), "X", targetHue)
+		reading := getProjFigureNumber(img, int(projFigX), int(projFigY), "X", targetHue)
+		finalXProjList[gridX] = reading.Count
+		if !reading.Agreed {
+			confidence = min(confidence, reading.Confidence)
+		}
 	}
 
 	// Y Projection (Left Column)
@@ -196,16 +229,22 @@ func getProjDesc(ctx *maa.Context, img image.Image, boardSize [2]int, targetHue
 		gridIdxRel := float64(gridY) - float64(H-1)/2.0
 		projFigY := BOARD_CENTER_BLOCK_LT_Y + gridIdxRel*BOARD_BLOCK_H
 
-		finalYProjList[gridY] = getProjFigureNumber(ctx, img, int(projFigX), int(projFigY), "Y", targetHue)
+		reading := getProjFigureNumber(img, int(projFigX), int(projFigY), "Y", targetHue)
+		finalYProjList[gridY] = reading.Count
+		if !reading.Agreed {
+			confidence = min(confidence, reading.Confidence)
+		}
 	}
 
 	return &ProjDesc{
 		XProjList: finalXProjList,
 		YProjList: finalYProjList,
-	}
+	}, confidence
 }
 
-func getProjFigureNumber(ctx *maa.Context, img image.Image, ltX, ltY int, axis string, targetHue int) int {
+// scanProjFigureNumber counts the bars of a projection figure from how far the target hue reaches
+// out from the board, assuming the bars are PROJ_EACH_GAP apart after PROJ_INIT_GAP
+func scanProjFigureNumber(img image.Image, ltX, ltY int, axis string, targetHue int) int {
 	samplingPoints := []float64{0.333, 0.5, 0.667}
 	maxOffset := 0
 
@@ -266,48 +305,103 @@ func getProjFigureNumber(ctx *maa.Context, img image.Image, ltX, ltY int, axis s
 	return result
 }
 
-func getAllPuzzleDesc(ctx *maa.Context, img image.Image) []*PuzzleDesc {
-	thumbs := getAllPuzzleThumbLoc(img)
-	log.Info().Interface("thumbs", thumbs).Msg("Puzzle thumbnail positions")
+// getAllPuzzleDesc pages through the thumbnail panel and previews every puzzle on it.
+// The panel is left on the first page.
+func getAllPuzzleDesc(r recognizer, img image.Image, palette *Palette) []*PuzzleDesc {
+	pageSize := PUZZLE_THUMB_MAX_ROWS * PUZZLE_THUMB_MAX_COLS
 
 	var puzzleList []*PuzzleDesc
-	for _, thumb := range thumbs {
-		// Wait for dragging CD
-		ctx.WaitFreezes(100*time.Millisecond, (*maa.Rect)(&[4]int{
-			int(PUZZLE_THUMB_START_X),
-			int(PUZZLE_THUMB_START_Y),
-			int(float64(PUZZLE_THUMB_MAX_COLS) * PUZZLE_THUMB_W),
-			int(float64(PUZZLE_THUMB_MAX_ROWS) * PUZZLE_THUMB_H),
-		}))
-
-		// Preview this puzzle
-		desc := doPreviewPuzzle(ctx, thumb[0], thumb[1])
-		if desc != nil {
-			puzzleList = append(puzzleList, desc)
-			log.Info().Interface("puzzle", desc).Msg("Puzzle structure")
+	var prevImg image.Image
+	page := 0
+	for ; page < PUZZLE_THUMB_MAX_PAGES; page++ {
+		if page > 0 {
+			if img = r.scrollThumbPage(page); img == nil {
+				break
+			}
+			if getAreaDiff(img, prevImg, getThumbPanelRect()) < PUZZLE_THUMB_PAGE_DIFF_GRT {
+				// Scrolling did not change the panel, the previous page was the last one
+				log.Debug().Int("page", page).Msg("Puzzle thumbnail panel did not scroll")
+				break
+			}
 		}
+
+		thumbs := getAllPuzzleThumbLoc(img)
+		log.Info().Int("page", page).Interface("thumbs", thumbs).Msg("Puzzle thumbnail positions")
+
+		for slot, thumb := range thumbs {
+			// Preview this puzzle
+			previewImg := r.previewPuzzle(page*pageSize+slot, thumb[0], thumb[1])
+			if previewImg == nil {
+				continue
+			}
+			desc := getPuzzleDesc(previewImg, palette)
+			if desc != nil {
+				desc.Page = page
+				desc.Slot = slot
+				puzzleList = append(puzzleList, desc)
+				log.Info().Interface("puzzle", desc).Msg("Puzzle structure")
+			}
+		}
+
+		if len(thumbs) < pageSize {
+			break
+		}
+		prevImg = img
+	}
+
+	if page >= PUZZLE_THUMB_MAX_PAGES {
+		// Every page is full, likely a false-positive
+		log.Warn().Int("pages", page).Msg("Detected too many puzzle thumbnail pages, skipping")
+		puzzleList = nil
+	}
+	if page > 0 {
+		r.scrollThumbPage(0)
 	}
 	return puzzleList
 }
 
-func doEnsureTab(ctx *maa.Context, img image.Image) image.Image {
-	rect1 := image.Rect(int(TAB_1_X), int(TAB_Y), int(TAB_1_X+TAB_W), int(TAB_Y+TAB_H))
-	rect2 := image.Rect(int(TAB_2_X), int(TAB_Y), int(TAB_2_X+TAB_W), int(TAB_Y+TAB_H))
-
-	_, _, val1 := getAreaHSV(img, rect1)
-	_, _, val2 := getAreaHSV(img, rect2)
-	log.Debug().Float64("val1", val1).Float64("val2", val2).Msg("Checking tab selection state")
-
-	var ctrl = ctx.GetTasker().GetController()
+// getActiveTab returns the index of the active board tab, which is the brightest one
+func getActiveTab(img image.Image) int {
+	active, activeVal := 0, -1.0
+	for i, x := range TAB_X_LIST {
+		_, _, val := getAreaHSV(img, image.Rect(int(x), int(TAB_Y), int(x+TAB_W), int(TAB_Y+TAB_H)))
+		log.Debug().Int("tab", i).Float64("val", val).Msg("Checking tab selection state")
+		// Ties go to the later tab
+		if val >= activeVal {
+			active, activeVal = i, val
+		}
+	}
+	return active
+}
 
-	// If tab 1 brightness is not greater than tab 2, it's likely on tab 2
-	if val1 <= val2 {
-		log.Info().Msg("Tab 2 detected as active, switching back to Tab 1")
+// doSelectTab switches to the given board tab and returns a fresh screenshot,
+// or nil if the tab cannot be activated, e.g. the board has fewer tabs
+func doSelectTab(ctx *maa.Context, img image.Image, tab int) image.Image {
+	ctrl := ctx.GetTasker().GetController()
+	for presses := 0; ; presses++ {
+		active := getActiveTab(img)
+		if active == tab {
+			break
+		}
+		if presses >= len(TAB_X_LIST)-1 {
+			log.Info().Int("tab", tab).Int("active", active).Msg("Board tab is not available")
+			return nil
+		}
+		log.Info().Int("tab", tab).Int("active", active).Msg("Switching board tab")
 		ctrl.PostClickKey(9) // Tab
 		time.Sleep(500 * time.Millisecond)
+		if img = doCaptureImage(ctx); img == nil {
+			return nil
+		}
 	}
 
 	// Then refresh screenshot
+	return doCaptureImage(ctx)
+}
+
+// doCaptureImage takes a fresh screenshot
+func doCaptureImage(ctx *maa.Context) image.Image {
+	ctrl := ctx.GetTasker().GetController()
 	ctrl.PostScreencap().Wait()
 	newImg, err := ctrl.CacheImage()
 	if err != nil {
@@ -320,12 +414,12 @@ func doEnsureTab(ctx *maa.Context, img image.Image) image.Image {
 		log.Error().Msg("Failed to capture image")
 		return nil
 	}
-	return newImg
+	return normalizeScreen(newImg)
 }
 
-func getPuzzleDesc(img image.Image) *PuzzleDesc {
+func getPuzzleDesc(img image.Image, palette *Palette) *PuzzleDesc {
 	blocks := [][2]int{}
-	var totalHue float64
+	var totalHue, totalSat, totalVal float64
 	count := 0
 	// Center block is at (0, 0) relative to core
 	// Coordinates of the center block in the preview image
@@ -354,6 +448,8 @@ func getPuzzleDesc(img image.Image) *PuzzleDesc {
 			if isBlock {
 				blocks = append(blocks, [2]int{offsetX, offsetY})
 				totalHue += hue
+				totalSat += sat
+				totalVal += val
 				count++
 			}
 		}
@@ -361,10 +457,17 @@ func getPuzzleDesc(img image.Image) *PuzzleDesc {
 	if count == 0 {
 		return nil
 	}
-	return &PuzzleDesc{
+	desc := &PuzzleDesc{
 		Blocks: blocks,
 		Hue:    int(totalHue / float64(count)),
 	}
+	sat, val := totalSat/float64(count), totalVal/float64(count)
+	if palette.match(desc.Hue, sat, val) == nil {
+		log.Warn().
+			Int("hue", desc.Hue).Float64("sat", sat).Float64("val", val).
+			Msg("Puzzle color matches no palette color")
+	}
+	return desc
 }
 
 func getAllPuzzleThumbLoc(img image.Image) [][2]int {
@@ -400,16 +503,11 @@ func getAllPuzzleThumbLoc(img image.Image) [][2]int {
 		}
 	}
 
-	if len(results) >= PUZZLE_THUMB_MAX_ROWS*PUZZLE_THUMB_MAX_COLS {
-		// False-positive
-		log.Warn().Int("count", len(results)).Msg("Detected too many puzzle thumbnails, skipping")
-		return [][2]int{}
-	}
-
 	return results
 }
 
-func doPreviewPuzzle(ctx *maa.Context, thumbX, thumbY int) *PuzzleDesc {
+// doPreviewPuzzle drags the thumbnail to the preview area and returns the screenshot taken there
+func doPreviewPuzzle(ctx *maa.Context, thumbX, thumbY int) image.Image {
 	ctrl := ctx.GetTasker().GetController()
 	log.Debug().Int("thumbX", thumbX).Int(" thumbY", thumbY).Msg("Previewing puzzle thumbnail")
 
@@ -445,28 +543,53 @@ func doPreviewPuzzle(ctx *maa.Context, thumbX, thumbY int) *PuzzleDesc {
 
 	// 3. Touch Up (Release)
 	aw.TouchUpSync(100)
+	return normalizeScreen(previewImg)
+}
+
+// doScrollThumbPage scrolls the thumbnail panel back to the top, then down to the given page
+func doScrollThumbPage(ctx *maa.Context, page int) {
+	log.Debug().Int("page", page).Msg("Scrolling puzzle thumbnail panel")
+	panel := getThumbPanelRect()
+	center := panel.Min.Add(panel.Max).Div(2)
+
+	aw := NewActionWrapper(ctx.GetTasker().GetController())
+	aw.TouchUpSync(100)
+	aw.TouchMoveSync(0, center.X, center.Y, 100)
+	for range PUZZLE_THUMB_MAX_PAGES * PUZZLE_THUMB_SCROLLS_PER_PAGE {
+		aw.ScrollSync(0, PUZZLE_THUMB_SCROLL_STEP, 50)
+	}
+	for range page * PUZZLE_THUMB_SCROLLS_PER_PAGE {
+		aw.ScrollSync(0, -PUZZLE_THUMB_SCROLL_STEP, 50)
+	}
+	time.Sleep(500 * time.Millisecond)
+}
+
+// getBoardBlockColor returns the hue of the board block at grid index (gridX, gridY),
+// and whether the block is filled with color, by a locked block or a placed puzzle
+func getBoardBlockColor(img image.Image, gridX, gridY, boardW, boardH int) (int, bool) {
+	ltX, ltY := convertBoardCoordToLTCoord(gridX, gridY, boardW, boardH)
+	rect := image.Rect(ltX, ltY, ltX+int(BOARD_BLOCK_W), ltY+int(BOARD_BLOCK_H))
 
-	// 4. Analyze
-	return getPuzzleDesc(previewImg)
+	hue, sat, val := getAreaHSV(img, rect)
+	return int(hue), sat > BOARD_LOCKED_COLOR_SAT_GRT && val > BOARD_LOCKED_COLOR_VAL_GRT
 }
 
-func getLockedBlocksDesc(img image.Image, boardW, boardH int) []*LockedBlockDesc {
+func getLockedBlocksDesc(img image.Image, boardW, boardH int, palette *Palette) []*LockedBlockDesc {
 	locked := []*LockedBlockDesc{}
 
 	for gridY := range boardH {
 		for gridX := range boardW {
-			// Get LT coordinate from Grid Index (gridX, gridY)
-			ltX, ltY := convertBoardCoordToLTCoord(gridX, gridY, boardW, boardH)
-			rect := image.Rect(ltX, ltY, ltX+int(BOARD_BLOCK_W), ltY+int(BOARD_BLOCK_H))
-
-			hue, sat, val := getAreaHSV(img, rect)
-			isLocked := sat > BOARD_LOCKED_COLOR_SAT_GRT && val > BOARD_LOCKED_COLOR_VAL_GRT
-
+			hue, isLocked := getBoardBlockColor(img, gridX, gridY, boardW, boardH)
 			if isLocked {
+				if palette.matchHue(hue) == nil {
+					log.Warn().Int("x", gridX).Int("y", gridY).Int("hue", hue).Msg("Locked block color matches no palette color")
+				}
+				// Get LT coordinate from Grid Index (gridX, gridY)
+				ltX, ltY := convertBoardCoordToLTCoord(gridX, gridY, boardW, boardH)
 				locked = append(locked, &LockedBlockDesc{
 					Loc:    [2]int{gridX, gridY},
 					RawLoc: [2]int{ltX, ltY},
-					Hue:    int(hue),
+					Hue:    hue,
 				})
 			}
 		}
@@ -474,8 +597,8 @@ func getLockedBlocksDesc(img image.Image, boardW, boardH int) []*LockedBlockDesc
 	return locked
 }
 
-func getBannedBlocksLTCoord(ctx *maa.Context, img image.Image) [][2]int {
-	result := matchTemplateAll(ctx, img, "PuzzleSolver/BlockBanned.png", []int{
+func getBannedBlocksLTCoord(r recognizer, img image.Image) [][2]int {
+	result := r.matchTemplateAll(img, "PuzzleSolver/BlockBanned.png", []int{
 		int(BOARD_X_LOWER_BOUND),
 		int(BOARD_Y_LOWER_BOUND),
 		int(BOARD_X_UPPER_BOUND - BOARD_X_LOWER_BOUND),
@@ -488,58 +611,49 @@ func getBannedBlocksLTCoord(ctx *maa.Context, img image.Image) [][2]int {
 	return blocks
 }
 
-func (r *Recognition) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (*maa.CustomRecognitionResult, bool) {
-	log.Info().
-		Str("recognition", arg.CustomRecognitionName).
-		Msg("Starting PuzzleSolver recognition")
+var errNoPuzzles = errors.New("no puzzles detected or invalid puzzles")
+
+var errNoTab = errors.New("board tab is not available")
 
-	img := arg.Img // 1280x720 for MaaEnd
+// recognizeBoard runs the whole recognition pipeline on one screenshot, reading the board of the given tab
+func recognizeBoard(r recognizer, img image.Image, palette *Palette, tab int) (*BoardDesc, error) {
+	// 1. Select the tab first, the thumbnail panel shows the puzzles of the active tab only
+	img = r.selectTab(img, tab)
 	if img == nil {
-		log.Error().Msg("Prepared image is nil")
-		return nil, false
+		return nil, errNoTab
 	}
 
-	// 1. Find all puzzles to be placed
-	puzzleList := getAllPuzzleDesc(ctx, img)
+	// 2. Find all puzzles to be placed, then determine board size
+	puzzleList := getAllPuzzleDesc(r, img, palette)
 
 	if len(puzzleList) == 0 {
-		log.Info().Msg("No puzzles detected or invalid puzzles")
-		return &maa.CustomRecognitionResult{
-			Box:    arg.Roi,
-			Detail: `{}`,
-		}, false
+		return nil, errNoPuzzles
 	}
 
-	// 2. Ensure tab state and determine board size (moved from step 4)
-	img = doEnsureTab(ctx, img)
-	if img == nil {
-		log.Error().Msg("Failed to ensure tab state: screenshot capture failed")
-		return nil, false
-	}
-
-	boardSize := getPossibleBoardSize(ctx, img)
+	boardSize := getPossibleBoardSize(r, img)
 	if boardSize[0] == 0 || boardSize[1] == 0 {
-		log.Error().Msg("Failed to determine board size")
-		return nil, false
+		return nil, errors.New("failed to determine board size")
 	}
 	log.Info().Int("boardW", boardSize[0]).Int("boardH", boardSize[1]).Msg("Determined possible board size")
 
 	// 3. Find banned and locked blocks
-	banned := getBannedBlocksLTCoord(ctx, img)
+	banned := getBannedBlocksLTCoord(r, img)
 	log.Info().Interface("banned", banned).Msg("Puzzle board banned blocks")
 
-	locked := getLockedBlocksDesc(img, boardSize[0], boardSize[1])
+	locked := getLockedBlocksDesc(img, boardSize[0], boardSize[1], palette)
 	log.Info().Interface("locked", locked).Msg("Puzzle board locked blocks")
 
 	// 4. Find possible hues from puzzles
-	hueList := getPossibleHues(puzzleList)
+	hueList := getPossibleHues(puzzleList, palette)
 	var projDescList []ProjDesc
 	var lockedBlockList [][]*LockedBlockDesc
+	projConfidence := 1.0
 
 	// 5. For each hue, determine board projection and locked blocks
 	for _, hue := range hueList {
-		projDesc := getProjDesc(ctx, img, boardSize, hue)
-		log.Debug().Int("hue", hue).Interface("projDesc", projDesc).Msg("Puzzle board projection description for hue")
+		projDesc, confidence := getProjDesc(img, boardSize, hue)
+		projConfidence = min(projConfidence, confidence)
+		log.Debug().Int("hue", hue).Interface("projDesc", projDesc).Float64("confidence", confidence).Msg("Puzzle board projection description for hue")
 
 		// Validate projection list dimensions match board size
 		if len(projDesc.XProjList) != boardSize[0] || len(projDesc.YProjList) != boardSize[1] {
@@ -548,7 +662,7 @@ func (r *Recognition) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (*maa
 				Int("XProjLen", len(projDesc.XProjList)).Int("YProjLen", len(projDesc.YProjList)).
 				Int("boardW", boardSize[0]).Int("boardH", boardSize[1]).
 				Msg("Projection list length mismatch with board dimensions")
-			return nil, false
+			return nil, errors.New("projection list length mismatch with board dimensions")
 		}
 
 		// Get locked blocks for this hue
@@ -566,7 +680,9 @@ func (r *Recognition) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (*maa
 	}
 
 	// 6. Construct board description
-	boardDesc := &BoardDesc{
+	bd := &BoardDesc{
+		Version:         BoardDescVersion,
+		Tab:             tab,
 		W:               boardSize[0],
 		H:               boardSize[1],
 		ProjDescList:    projDescList,
@@ -575,6 +691,67 @@ func (r *Recognition) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (*maa
 		PuzzleList:      puzzleList,
 		HueList:         hueList,
 	}
+	_, bd.Confidence = ValidateBoardDesc(bd)
+	bd.Confidence = min(bd.Confidence, projConfidence)
+	return bd, nil
+}
+
+func (r *Recognition) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (*maa.CustomRecognitionResult, bool) {
+	log.Info().
+		Str("recognition", arg.CustomRecognitionName).
+		Msg("Starting PuzzleSolver recognition")
+
+	img := arg.Img
+	if img == nil {
+		log.Error().Msg("Prepared image is nil")
+		return nil, false
+	}
+	img = normalizeScreen(img) // Into WORK_W x WORK_H
+
+	// Opt-in session recording for bug reports, continued by PuzzleAction
+	var params struct {
+		Record bool `json:"record"`
+	}
+	if arg.CustomRecognitionParam != "" {
+		if err := json.Unmarshal([]byte(arg.CustomRecognitionParam), &params); err != nil {
+			log.Warn().Err(err).Msg("Failed to parse CustomRecognitionParam")
+		}
+	}
+	startSession(params.Record)
+
+	palette := getPalette(defaultResourceDir)
+
+	// Read the first board tab holding puzzles, the action goes on with the tabs after it
+	var boardDesc *BoardDesc
+	for tab := range TAB_X_LIST {
+		if tab > 0 {
+			if img = doCaptureImage(ctx); img == nil {
+				return nil, false
+			}
+		}
+		bd, err := doRecognizeTab(ctx, img, palette, tab, PUZZLE_MIN_CONFIDENCE)
+		if errors.Is(err, errNoPuzzles) {
+			log.Info().Int("tab", tab).Msg("No puzzles on board tab")
+			continue
+		}
+		if errors.Is(err, errNoTab) {
+			break
+		}
+		if err != nil {
+			log.Error().Err(err).Int("tab", tab).Msg("Failed to recognize puzzle board")
+			return nil, false
+		}
+		boardDesc = bd
+		break
+	}
+	if boardDesc == nil {
+		log.Info().Msg("No puzzles detected on any board tab")
+		discardSession()
+		return &maa.CustomRecognitionResult{
+			Box:    arg.Roi,
+			Detail: `{}`,
+		}, false
+	}
 	log.Info().Interface("boardDesc", boardDesc).Msg("Puzzle board description")
 
 	// 7. Convert to JSON and return
@@ -584,7 +761,7 @@ func (r *Recognition) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (*maa
 		detailJSON = []byte(`{}`)
 	}
 
-	log.Info().Msg("Finished PuzzleSolver recognition")
+	log.Info().Float64("confidence", boardDesc.Confidence).Msg("Finished PuzzleSolver recognition")
 	return &maa.CustomRecognitionResult{
 		Box:    arg.Roi,
 		Detail: string(detailJSON),
@@ -0,0 +1,285 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// recognizer provides the parts of the recognition pipeline that depend on the game or the framework.
+// liveRecognizer drives the game through a maa.Context,
+// fixtureRecognizer replays recorded screenshots and matches templates in pure Go.
+type recognizer interface {
+	// matchTemplateAll finds up to maxMatch occurrences of the template within roi, best first
+	matchTemplateAll(img image.Image, template string, roi []int, maxMatch int) []TemplateMatchDTO
+	// previewPuzzle returns a screenshot with the index-th puzzle thumbnail dragged to the preview area
+	previewPuzzle(index, thumbX, thumbY int) image.Image
+	// scrollThumbPage scrolls the thumbnail panel to the given page and returns a fresh screenshot,
+	// or nil if the page cannot be shown
+	scrollThumbPage(page int) image.Image
+	// selectTab makes the given board tab active and returns a fresh screenshot,
+	// or nil if there is no such tab
+	selectTab(img image.Image, tab int) image.Image
+}
+
+/* ******** Live ******** */
+
+type liveRecognizer struct {
+	ctx *maa.Context
+	rec *passRecorder // Saves the screenshots taken if a session is being recorded
+}
+
+func (r *liveRecognizer) matchTemplateAll(img image.Image, template string, roi []int, maxMatch int) []TemplateMatchDTO {
+	return matchTemplateAll(r.ctx, img, template, roi, maxMatch)
+}
+
+func (r *liveRecognizer) previewPuzzle(index, thumbX, thumbY int) image.Image {
+	// Wait for dragging CD
+	panel := getScreenTransform().rectToScreen(getThumbPanelRect())
+	r.ctx.WaitFreezes(100*time.Millisecond, (*maa.Rect)(&[4]int{
+		panel.Min.X,
+		panel.Min.Y,
+		panel.Dx(),
+		panel.Dy(),
+	}))
+	img := doPreviewPuzzle(r.ctx, thumbX, thumbY)
+	r.rec.saveImage(fmt.Sprintf(fixturePreviewFile, index), img)
+	return img
+}
+
+func (r *liveRecognizer) scrollThumbPage(page int) image.Image {
+	doScrollThumbPage(r.ctx, page)
+	img := doCaptureImage(r.ctx)
+	if page > 0 {
+		r.rec.saveImage(fmt.Sprintf(fixturePageFile, page), img)
+	}
+	return img
+}
+
+func (r *liveRecognizer) selectTab(img image.Image, tab int) image.Image {
+	img = doSelectTab(r.ctx, img, tab)
+	r.rec.saveImage(fixtureTabFile, img)
+	if img != nil {
+		setBoardScreen(tab, img)
+	}
+	return img
+}
+
+// boardScreens keeps the screenshot each board tab was last recognized on, for the solution overlay
+var (
+	boardScreensMu sync.Mutex
+	boardScreens   = make(map[int]image.Image)
+)
+
+func setBoardScreen(tab int, img image.Image) {
+	boardScreensMu.Lock()
+	defer boardScreensMu.Unlock()
+	boardScreens[tab] = img
+}
+
+func getBoardScreen(tab int) image.Image {
+	boardScreensMu.Lock()
+	defer boardScreensMu.Unlock()
+	return boardScreens[tab]
+}
+
+// doRecognizeBoard runs the recognition pipeline on the live game, recording the pass if a session is active
+func doRecognizeBoard(ctx *maa.Context, img image.Image, palette *Palette, tab int) (*BoardDesc, *passRecorder, error) {
+	rec := newPassRecorder(tab)
+	rec.saveImage(fixtureScreenFile, img)
+	bd, err := recognizeBoard(&liveRecognizer{ctx: ctx, rec: rec}, img, palette, tab)
+	rec.finish(bd, err)
+	return bd, rec, err
+}
+
+// doRecognizeTab recognizes the board of a tab and validates it, recognizing it again on a fresh screenshot
+// up to PUZZLE_RECOGNITION_MAX_ATTEMPTS times while it is inconsistent or less confident than minConfidence. It returns the most confident board,
+// errNoTab if the tab cannot be selected, or errNoPuzzles if the tab has no puzzles.
+func doRecognizeTab(ctx *maa.Context, img image.Image, palette *Palette, tab int, minConfidence float64) (*BoardDesc, error) {
+	var boardDesc *BoardDesc
+	var boardRec *passRecorder
+	var lastErr error
+	for attempt := 1; attempt <= PUZZLE_RECOGNITION_MAX_ATTEMPTS; attempt++ {
+		if attempt > 1 {
+			if img = doCaptureImage(ctx); img == nil {
+				break
+			}
+		}
+
+		bd, rec, err := doRecognizeBoard(ctx, img, palette, tab)
+		if errors.Is(err, errNoTab) || (errors.Is(err, errNoPuzzles) && boardDesc == nil) {
+			return nil, err
+		}
+		if err != nil {
+			log.Error().Err(err).Int("tab", tab).Int("attempt", attempt).Msg("Failed to recognize puzzle board")
+			lastErr = err
+			continue
+		}
+
+		issues, _ := ValidateBoardDesc(bd)
+		if boardDesc == nil || bd.Confidence > boardDesc.Confidence {
+			boardDesc, boardRec = bd, rec
+		}
+		if len(issues) == 0 && bd.Confidence >= minConfidence {
+			break
+		}
+		log.Warn().
+			Int("tab", tab).
+			Int("attempt", attempt).
+			Float64("confidence", bd.Confidence).
+			Float64("minConfidence", minConfidence).
+			Strs("issues", issues).
+			Msg("Puzzle board failed validation")
+	}
+	if boardDesc == nil {
+		if lastErr == nil {
+			lastErr = errors.New("no screenshot to recognize")
+		}
+		return nil, lastErr
+	}
+	boardRec.choose()
+	return boardDesc, nil
+}
+
+/* ******** Fixture ******** */
+
+// Fixture layout, one directory per recorded screen, of any 16:9 resolution:
+//
+//	screen.png       the screenshot passed to PuzzleRecognition, with the first board tab active
+//	page_<p>.png     the screenshot after scrolling the thumbnail panel to page p > 0, if it has more pages
+//	preview_<i>.png  the screenshot taken while previewing the i-th puzzle thumbnail, counted across pages
+//	tab.png          the screenshot after switching to the board tab, if it was taken, screen.png otherwise
+//	golden.json      the expected BoardDesc
+const (
+	fixtureScreenFile  = "screen.png"
+	fixturePageFile    = "page_%d.png"
+	fixturePreviewFile = "preview_%d.png"
+	fixtureTabFile     = "tab.png"
+	fixtureGoldenFile  = "golden.json"
+)
+
+type fixtureRecognizer struct {
+	dir         string
+	templateDir string // The resource image directory templates are named relative to
+	tab         int    // The board tab the screenshots were taken on
+
+	mu        sync.Mutex
+	templates map[string]image.Image
+}
+
+func newFixtureRecognizer(dir, templateDir string, tab int) *fixtureRecognizer {
+	return &fixtureRecognizer{
+		dir:         dir,
+		templateDir: templateDir,
+		tab:         tab,
+		templates:   make(map[string]image.Image),
+	}
+}
+
+func (r *fixtureRecognizer) matchTemplateAll(img image.Image, template string, roi []int, maxMatch int) []TemplateMatchDTO {
+	tpl, err := r.loadTemplate(template)
+	if err != nil {
+		log.Error().Err(err).Str("template", template).Msg("Failed to load template")
+		return make([]TemplateMatchDTO, 0)
+	}
+	return matchTemplateImage(img, tpl, roi, TEMPLATE_MATCH_THRESHOLD, maxMatch)
+}
+
+func (r *fixtureRecognizer) previewPuzzle(index, thumbX, thumbY int) image.Image {
+	img, err := loadScreenPNG(filepath.Join(r.dir, fmt.Sprintf(fixturePreviewFile, index)))
+	if err != nil {
+		log.Error().Err(err).Int("index", index).Msg("Failed to load preview screenshot")
+		return nil
+	}
+	return img
+}
+
+func (r *fixtureRecognizer) scrollThumbPage(page int) image.Image {
+	if page == 0 {
+		// The first page as shown after switching to the board tab
+		if img, err := loadScreenPNG(filepath.Join(r.dir, fixtureTabFile)); err == nil {
+			return img
+		}
+		img, _ := loadScreenPNG(filepath.Join(r.dir, fixtureScreenFile))
+		return img
+	}
+	img, err := loadScreenPNG(filepath.Join(r.dir, fmt.Sprintf(fixturePageFile, page)))
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+func (r *fixtureRecognizer) selectTab(img image.Image, tab int) image.Image {
+	if tab != r.tab {
+		return nil
+	}
+	if tabImg, err := loadScreenPNG(filepath.Join(r.dir, fixtureTabFile)); err == nil {
+		return tabImg
+	}
+	return img
+}
+
+func (r *fixtureRecognizer) loadTemplate(name string) (image.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tpl, ok := r.templates[name]; ok {
+		return tpl, nil
+	}
+	tpl, err := loadPNG(filepath.Join(r.templateDir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	r.templates[name] = tpl
+	return tpl, nil
+}
+
+// loadPNG reads a screenshot or template as is
+func loadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// loadScreenPNG reads a recorded screenshot of any resolution into the work space
+func loadScreenPNG(path string) (image.Image, error) {
+	img, err := loadPNG(path)
+	if err != nil {
+		return nil, err
+	}
+	return getImageTransform(img).normalizeImage(img), nil
+}
+
+// RecognizeFixture runs the recognition pipeline on a recorded screen directory (see fixtureScreenFile),
+// with the templates and palette of resourceDir, e.g. assets/resource.
+func RecognizeFixture(dir, resourceDir string) (*BoardDesc, error) {
+	return recognizeFixture(dir, resourceDir, 0)
+}
+
+func recognizeFixture(dir, resourceDir string, tab int) (*BoardDesc, error) {
+	img, err := loadScreenPNG(filepath.Join(dir, fixtureScreenFile))
+	if err != nil {
+		return nil, err
+	}
+	palette, err := LoadPalette(resourceDir)
+	if err != nil {
+		return nil, err
+	}
+	bd, err := recognizeBoard(newFixtureRecognizer(dir, filepath.Join(resourceDir, "image"), tab), img, palette, tab)
+	if err != nil {
+		return nil, err
+	}
+	return bd, nil
+}
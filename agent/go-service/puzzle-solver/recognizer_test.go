@@ -0,0 +1,280 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden.json of the recorded screens")
+
+// resourceDir is where the pipeline templates and palette live, relative to this package
+var resourceDir = filepath.Join("..", "..", "..", "assets", "resource")
+
+// TestRecognizeRecordedScreens runs the recognition pipeline on every recorded screen under
+// testdata/screens and compares the result with its golden BoardDesc.
+// Run with -update after a game UI change has been checked by hand.
+func TestRecognizeRecordedScreens(t *testing.T) {
+	for _, dir := range screenFixtureDirs(t) {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			got, err := RecognizeFixture(dir, resourceDir)
+			if err != nil {
+				t.Fatalf("recognize: %v", err)
+			}
+
+			goldenPath := filepath.Join(dir, fixtureGoldenFile)
+			if *updateGolden {
+				data, err := json.MarshalIndent(got, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(goldenPath, append(data, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := LoadBoardDesc(goldenPath)
+			if err != nil {
+				t.Fatalf("load golden: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("board desc mismatch\n got: %s\nwant: %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// screenFixtureDirs lists the screen directories under testdata/screens, there must be at least one
+func screenFixtureDirs(tb testing.TB) []string {
+	tb.Helper()
+	entries, err := os.ReadDir(filepath.Join("testdata", "screens"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, filepath.Join("testdata", "screens", e.Name()))
+		}
+	}
+	if len(dirs) == 0 {
+		tb.Fatal("no recorded screens found in testdata/screens")
+	}
+	return dirs
+}
+
+func TestMatchTemplateImage(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomImage := func(w, h int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := range img.Pix {
+			img.Pix[i] = uint8(rng.Intn(256))
+		}
+		return img
+	}
+
+	for _, size := range []int{10, 24} { // Below and above templateCoarseMinArea
+		tpl := randomImage(size, size)
+		img := image.NewRGBA(image.Rect(0, 0, 200, 120))
+		for i := range img.Pix {
+			img.Pix[i] = 40
+		}
+		places := []image.Point{{20, 30}, {130, 70}}
+		for _, pt := range places {
+			for y := range size {
+				for x := range size {
+					img.Set(pt.X+x, pt.Y+y, tpl.At(x, y))
+				}
+			}
+		}
+		img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+
+		matches := matchTemplateImage(img, tpl, []int{10, 10, 180, 100}, TEMPLATE_MATCH_THRESHOLD, 8)
+		if len(matches) != len(places) {
+			t.Fatalf("size %d: got %d matches %v, want %d", size, len(matches), matches, len(places))
+		}
+		found := map[image.Point]bool{}
+		for _, m := range matches {
+			found[image.Point{m.X, m.Y}] = true
+			if m.Score < 0.99 {
+				t.Errorf("size %d: match at (%d, %d) scores %v", size, m.X, m.Y, m.Score)
+			}
+		}
+		for _, pt := range places {
+			if !found[pt] {
+				t.Errorf("size %d: template at %v not found in %v", size, pt, matches)
+			}
+		}
+	}
+}
+
+// pagingRecognizer draws a thumbnail panel holding n puzzles, the i-th of which has i%3+1 blocks in a row
+type pagingRecognizer struct {
+	n         int
+	stuck     bool // Scrolling does not move the panel
+	lastPage  int
+	previewed []int
+}
+
+func (r *pagingRecognizer) matchTemplateAll(image.Image, string, []int, int) []TemplateMatchDTO {
+	return nil
+}
+
+func (r *pagingRecognizer) previewPuzzle(index, thumbX, thumbY int) image.Image {
+	r.previewed = append(r.previewed, index)
+	img := newFilledImage(color.RGBA{30, 30, 30, 255})
+	for k := 0; k <= index%3; k++ {
+		cx := PUZZLE_PREVIEW_MV_CENTER_X + float64(k)*PUZZLE_W
+		cy := PUZZLE_PREVIEW_MV_CENTER_Y
+		x1, y1 := int(cx-PUZZLE_W/2), int(cy-PUZZLE_H/2)
+		fillChecker(img, image.Rect(x1, y1, x1+int(PUZZLE_W), y1+int(PUZZLE_H)),
+			color.RGBA{20, 90, 200, 255}, color.RGBA{80, 150, 255, 255})
+	}
+	return img
+}
+
+func (r *pagingRecognizer) scrollThumbPage(page int) image.Image {
+	r.lastPage = page
+	if r.stuck {
+		page = 0
+	}
+	pageSize := PUZZLE_THUMB_MAX_ROWS * PUZZLE_THUMB_MAX_COLS
+	img := newFilledImage(color.RGBA{30, 30, 30, 255})
+	for slot := 0; slot < pageSize && page*pageSize+slot < r.n; slot++ {
+		x, y := getThumbCenter(slot)
+		rect := image.Rect(x-int(PUZZLE_THUMB_W)/2, y-int(PUZZLE_THUMB_H)/2, x+int(PUZZLE_THUMB_W)/2, y+int(PUZZLE_THUMB_H)/2)
+		dark, light := uint8(40+10*page), uint8(100+10*page) // Pages must look different
+		fillChecker(img, rect, color.RGBA{dark, dark, dark, 255}, color.RGBA{light, light, light, 255})
+	}
+	return img
+}
+
+func (r *pagingRecognizer) selectTab(img image.Image, tab int) image.Image {
+	return img
+}
+
+func newFilledImage(c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, WORK_W, WORK_H))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+// fillChecker fills rect with a checkerboard of 4px cells, coarse enough to survive resampling
+func fillChecker(img *image.RGBA, rect image.Rectangle, c1, c2 color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if (x/4+y/4)%2 == 0 {
+				img.SetRGBA(x, y, c1)
+			} else {
+				img.SetRGBA(x, y, c2)
+			}
+		}
+	}
+}
+
+func TestGetAllPuzzleDescPages(t *testing.T) {
+	pageSize := PUZZLE_THUMB_MAX_ROWS * PUZZLE_THUMB_MAX_COLS
+	for _, tc := range []struct {
+		name  string
+		n     int
+		stuck bool
+		want  int
+	}{
+		{"single page", 5, false, 5},
+		{"two pages", pageSize + 2, false, pageSize + 2},
+		{"full page that does not scroll", pageSize, true, pageSize},
+		{"every page full", pageSize * PUZZLE_THUMB_MAX_PAGES, false, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &pagingRecognizer{n: tc.n, stuck: tc.stuck}
+			puzzles := getAllPuzzleDesc(r, r.scrollThumbPage(0), defaultPalette)
+			if len(puzzles) != tc.want {
+				t.Fatalf("got %d puzzles, want %d", len(puzzles), tc.want)
+			}
+			for i, pd := range puzzles {
+				if pd.Page != i/pageSize || pd.Slot != i%pageSize {
+					t.Errorf("puzzle %d on page %d slot %d", i, pd.Page, pd.Slot)
+				}
+				if len(pd.Blocks) != i%3+1 {
+					t.Errorf("puzzle %d has %d blocks, want %d", i, len(pd.Blocks), i%3+1)
+				}
+			}
+			if r.lastPage != 0 {
+				t.Errorf("panel left on page %d", r.lastPage)
+			}
+		})
+	}
+}
+
+// tabRecognizer shows the puzzles of a pagingRecognizer only once the board tab is selected
+type tabRecognizer struct {
+	pagingRecognizer
+	selected bool
+}
+
+func (r *tabRecognizer) selectTab(img image.Image, tab int) image.Image {
+	r.selected = true
+	return r.scrollThumbPage(0)
+}
+
+func (r *tabRecognizer) previewPuzzle(index, thumbX, thumbY int) image.Image {
+	if !r.selected {
+		return nil
+	}
+	return r.pagingRecognizer.previewPuzzle(index, thumbX, thumbY)
+}
+
+func TestRecognizeBoardSelectsTabFirst(t *testing.T) {
+	r := &tabRecognizer{pagingRecognizer: pagingRecognizer{n: 3}}
+	_, err := recognizeBoard(r, newFilledImage(color.RGBA{30, 30, 30, 255}), defaultPalette, 1)
+	if errors.Is(err, errNoPuzzles) || len(r.previewed) != 3 {
+		t.Errorf("puzzles read before selecting the tab: %v, previewed %v", err, r.previewed)
+	}
+}
+
+func TestParseBoardDescFillsThumbSlots(t *testing.T) {
+	data := []byte(`{"W":3,"H":3,"HueList":[206],"PuzzleList":[` +
+		`{"Blocks":[[0,0]],"Hue":206},{"Blocks":[[0,0]],"Hue":206},{"Blocks":[[0,0]],"Hue":206},` +
+		`{"Blocks":[[0,0]],"Hue":206},{"Blocks":[[0,0]],"Hue":206},{"Blocks":[[0,0]],"Hue":206},` +
+		`{"Blocks":[[0,0]],"Hue":206},{"Blocks":[[0,0]],"Hue":206},{"Blocks":[[0,0]],"Hue":206}]}`)
+	bd, err := ParseBoardDesc(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page, slot := bd.getThumbSlot(8); page != 1 || slot != 0 {
+		t.Errorf("puzzle 8 on page %d slot %d, want page 1 slot 0", page, slot)
+	}
+	if page, slot := bd.getThumbSlot(3); page != 0 || slot != 3 {
+		t.Errorf("puzzle 3 on page %d slot %d, want page 0 slot 3", page, slot)
+	}
+}
+
+func TestGetActiveTab(t *testing.T) {
+	for active := range TAB_X_LIST {
+		img := newFilledImage(color.RGBA{30, 30, 30, 255})
+		for i, x := range TAB_X_LIST {
+			c := color.RGBA{90, 90, 90, 255}
+			if i == active {
+				c = color.RGBA{230, 230, 230, 255}
+			}
+			rect := image.Rect(int(x), int(TAB_Y), int(x+TAB_W), int(TAB_Y+TAB_H))
+			fillChecker(img, rect, c, c)
+		}
+		if got := getActiveTab(img); got != active {
+			t.Errorf("getActiveTab = %d, want %d", got, active)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Session layout, one timestamped directory under debug/puzzle/ per recorded PuzzleSolver run:
+//
+//	manifest.json    the SessionManifest
+//	pass_<n>/        one directory per recognition pass, in the fixture layout (see fixtureScreenFile),
+//	                 with tab.png taken after switching to the board tab, board.json, the BoardDesc,
+//	                 and solution.png, tab.png annotated with the placements, if the board was solved
+const (
+	sessionRootDir      = "puzzle"
+	sessionTimeFormat   = "20060102-150405"
+	sessionManifestFile = "manifest.json"
+	sessionPassDir      = "pass_%d"
+	sessionBoardFile    = "board.json"
+	sessionSolutionFile = "solution.png"
+)
+
+// SessionManifest describes a recorded session
+type SessionManifest struct {
+	CreatedAt time.Time      `json:"createdAt"`
+	Passes    []*SessionPass `json:"passes"`
+}
+
+// SessionPass is one recognition pass of a session, with the placements made from its board, if any
+type SessionPass struct {
+	Dir        string      `json:"dir"`
+	Tab        int         `json:"tab"`
+	Confidence float64     `json:"confidence"`
+	Issues     []string    `json:"issues,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Placements []Placement `json:"placements,omitempty"`
+}
+
+// session records a PuzzleSolver run into its directory
+type session struct {
+	dir      string
+	manifest SessionManifest
+	lastPass map[int]*SessionPass // Latest pass of each tab whose board is acted on
+}
+
+var (
+	sessionMu     sync.Mutex
+	activeSession *session
+)
+
+// startSession begins recording into a new directory under debug/puzzle/, removing the oldest
+// sessions beyond PUZZLE_RECORD_MAX_SESSIONS. Without record, it stops any previous session.
+func startSession(record bool) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	activeSession = nil
+	if !record {
+		return
+	}
+
+	root := filepath.Join(".", "debug", sessionRootDir)
+	now := time.Now()
+	dir := filepath.Join(root, now.Format(sessionTimeFormat))
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		dir = filepath.Join(root, fmt.Sprintf("%s-%d", now.Format(sessionTimeFormat), i))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Error().Err(err).Str("dir", dir).Msg("Failed to create puzzle session directory")
+		return
+	}
+	pruneSessions(root, PUZZLE_RECORD_MAX_SESSIONS)
+
+	activeSession = &session{
+		dir:      dir,
+		manifest: SessionManifest{CreatedAt: now},
+		lastPass: make(map[int]*SessionPass),
+	}
+	activeSession.save()
+	log.Info().Str("dir", dir).Msg("Recording puzzle session")
+}
+
+// discardSession removes the directory of the active session and stops recording
+func discardSession() {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if activeSession == nil {
+		return
+	}
+	if err := os.RemoveAll(activeSession.dir); err != nil {
+		log.Warn().Err(err).Str("dir", activeSession.dir).Msg("Failed to remove puzzle session directory")
+	}
+	activeSession = nil
+}
+
+// pruneSessions keeps the newest keep session directories under root
+func pruneSessions(root string, keep int) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	// Timestamped names sort in creation order
+	sort.Strings(dirs)
+	for len(dirs) > keep {
+		path := filepath.Join(root, dirs[0])
+		if err := os.RemoveAll(path); err != nil {
+			log.Warn().Err(err).Str("dir", path).Msg("Failed to remove old puzzle session")
+		}
+		dirs = dirs[1:]
+	}
+}
+
+func (s *session) save() {
+	data, err := json.MarshalIndent(&s.manifest, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode puzzle session manifest")
+		return
+	}
+	path := filepath.Join(s.dir, sessionManifestFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to write puzzle session manifest")
+	}
+}
+
+// passRecorder saves the screenshots of one recognition pass, it is a no-op when nil
+type passRecorder struct {
+	s    *session
+	pass *SessionPass
+}
+
+// newPassRecorder starts a pass of the active session, or returns nil if not recording
+func newPassRecorder(tab int) *passRecorder {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if activeSession == nil {
+		return nil
+	}
+	pass := &SessionPass{
+		Dir: fmt.Sprintf(sessionPassDir, len(activeSession.manifest.Passes)+1),
+		Tab: tab,
+	}
+	if err := os.MkdirAll(filepath.Join(activeSession.dir, pass.Dir), 0755); err != nil {
+		log.Error().Err(err).Msg("Failed to create puzzle session pass directory")
+		return nil
+	}
+	activeSession.manifest.Passes = append(activeSession.manifest.Passes, pass)
+	activeSession.save()
+	return &passRecorder{activeSession, pass}
+}
+
+func (pr *passRecorder) saveImage(name string, img image.Image) {
+	if pr == nil || img == nil {
+		return
+	}
+	path := filepath.Join(pr.s.dir, pr.pass.Dir, name)
+	if err := savePNG(path, img); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to save puzzle session screenshot")
+	}
+}
+
+// finish records the outcome of the pass
+func (pr *passRecorder) finish(bd *BoardDesc, err error) {
+	if pr == nil {
+		return
+	}
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if err != nil {
+		pr.pass.Error = err.Error()
+	}
+	if bd != nil {
+		pr.pass.Issues, _ = ValidateBoardDesc(bd)
+		pr.pass.Confidence = bd.Confidence
+		if data, err := json.MarshalIndent(bd, "", "  "); err == nil {
+			path := filepath.Join(pr.s.dir, pr.pass.Dir, sessionBoardFile)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				log.Error().Err(err).Str("path", path).Msg("Failed to save puzzle session board")
+			}
+		}
+	}
+	pr.s.save()
+}
+
+// choose makes the pass the one acted on for its tab
+func (pr *passRecorder) choose() {
+	if pr == nil {
+		return
+	}
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	pr.s.lastPass[pr.pass.Tab] = pr.pass
+}
+
+// recordPlacements adds the placements made on a tab to the pass its board was recognized in
+func recordPlacements(tab int, placements []Placement) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if activeSession == nil {
+		return
+	}
+	pass, ok := activeSession.lastPass[tab]
+	if !ok {
+		return
+	}
+	pass.Placements = append(pass.Placements, placements...)
+	activeSession.save()
+}
+
+// recordSolutionImage saves the annotated board screenshot of a tab into the pass its board was recognized in
+func recordSolutionImage(tab int, img image.Image) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if activeSession == nil {
+		return
+	}
+	pass, ok := activeSession.lastPass[tab]
+	if !ok {
+		return
+	}
+	path := filepath.Join(activeSession.dir, pass.Dir, sessionSolutionFile)
+	if err := savePNG(path, img); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to save puzzle solution image")
+	}
+}
+
+// LoadSessionManifest reads the manifest of a recorded session directory
+func LoadSessionManifest(dir string) (*SessionManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sessionManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m SessionManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ReplayResult is the outcome of replaying one pass of a recorded session
+type ReplayResult struct {
+	Pass         *SessionPass
+	Recorded     *BoardDesc  // The board recognized while recording, nil if the pass failed
+	Board        *BoardDesc  // The board recognized again from the recorded screenshots
+	BoardErr     error       // Why the board could not be recognized again
+	BoardDiffers bool        // Whether Board differs from Recorded
+	Placements   []Placement // The placements solved again from Recorded
+	SolveErr     error       // Why Recorded could not be solved again
+}
+
+// ReplaySession recognizes every pass of a recorded session again with the templates and palette
+// of resourceDir, and solves the recorded boards again, without the MAA framework.
+func ReplaySession(ctx context.Context, dir, resourceDir string, opts SolveOptions) ([]ReplayResult, error) {
+	m, err := LoadSessionManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ReplayResult, 0, len(m.Passes))
+	for _, pass := range m.Passes {
+		res := ReplayResult{Pass: pass}
+		passDir := filepath.Join(dir, pass.Dir)
+
+		res.Board, res.BoardErr = recognizeFixture(passDir, resourceDir, pass.Tab)
+		if data, err := os.ReadFile(filepath.Join(passDir, sessionBoardFile)); err == nil {
+			if res.Recorded, err = ParseBoardDesc(data); err != nil {
+				return nil, err
+			}
+		}
+
+		if res.Recorded != nil {
+			if res.Board != nil {
+				want, _ := json.Marshal(res.Recorded)
+				got, _ := json.Marshal(res.Board)
+				res.BoardDiffers = string(want) != string(got)
+			}
+			var result *SolveResult
+			if result, res.SolveErr = SolveAll(ctx, res.Recorded, opts); res.SolveErr == nil {
+				res.Placements = result.Solutions[0]
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSessionRecording(t *testing.T) {
+	bd, err := LoadBoardDesc(filepath.Join("testdata", "boards", "small_3x3_1hue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, bd.Confidence = ValidateBoardDesc(bd) // As recognizeBoard does
+	absResourceDir, err := filepath.Abs(resourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	defer func(keep int) { PUZZLE_RECORD_MAX_SESSIONS = keep }(PUZZLE_RECORD_MAX_SESSIONS)
+	PUZZLE_RECORD_MAX_SESSIONS = 2
+
+	placements := []Placement{{MachineX: 1, MachineY: 1, PuzzleIndex: 0}}
+	for range 3 {
+		startSession(true)
+		rec := newPassRecorder(0)
+		rec.saveImage(fixtureScreenFile, newFilledImage(color.RGBA{0, 0, 0, 255}))
+		rec.finish(bd, nil)
+		rec.choose()
+		recordPlacements(0, placements)
+		recordSolutionImage(0, newFilledImage(color.RGBA{0, 0, 0, 255}))
+	}
+	dir := activeSession.dir
+	startSession(false)
+	if activeSession != nil {
+		t.Error("still recording after the session stopped")
+	}
+	if newPassRecorder(0) != nil {
+		t.Error("recording without an active session")
+	}
+
+	sessions, err := os.ReadDir(filepath.Join("debug", sessionRootDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("%d sessions kept, want 2", len(sessions))
+	}
+
+	m, err := LoadSessionManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Passes) != 1 || m.Passes[0].Confidence != 1 || !reflect.DeepEqual(m.Passes[0].Placements, placements) {
+		t.Fatalf("manifest passes %+v", m.Passes)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, m.Passes[0].Dir, sessionSolutionFile)); err != nil {
+		t.Errorf("solution image not recorded: %v", err)
+	}
+
+	results, err := ReplaySession(context.Background(), dir, absResourceDir, SolveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("%d replay results, want 1", len(results))
+	}
+	r := results[0]
+	if !errors.Is(r.BoardErr, errNoPuzzles) {
+		t.Errorf("recognizing a blank screen: %v", r.BoardErr)
+	}
+	if r.Recorded == nil || r.SolveErr != nil || len(r.Placements) != len(bd.PuzzleList) {
+		t.Errorf("solving the recorded board: %d placements, %v", len(r.Placements), r.SolveErr)
+	}
+}
+
+func TestDiscardSession(t *testing.T) {
+	t.Chdir(t.TempDir())
+	startSession(true)
+	dir := activeSession.dir
+	discardSession()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("session directory still exists: %v", err)
+	}
+	if activeSession != nil {
+		t.Error("session still active")
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// hueLetter returns the short label used for the hue index in text renderings
+func hueLetter(hIdx int) byte {
+	if hIdx < 0 || hIdx >= 26 {
+		return '?'
+	}
+	return byte('a' + hIdx)
+}
+
+// getPieceMap maps every board cell to the index of the piece placed there, -1 if none
+func getPieceMap(board *Board, puzzles []*Puzzle, placements []Placement) ([][]int, error) {
+	pieceAt := make([][]int, board.YSize)
+	for y := range pieceAt {
+		pieceAt[y] = make([]int, board.XSize)
+		for x := range pieceAt[y] {
+			pieceAt[y][x] = -1
+		}
+	}
+	for _, p := range placements {
+		if p.PuzzleIndex < 0 || p.PuzzleIndex >= len(puzzles) {
+			return nil, fmt.Errorf("placement refers to unknown puzzle %d", p.PuzzleIndex)
+		}
+		deriv := puzzles[p.PuzzleIndex].getAllDerivatives()[((p.Rotation%4)+4)%4]
+		for _, block := range deriv.Blocks {
+			nx, ny := p.MachineX+block[0], p.MachineY+block[1]
+			if nx < 0 || nx >= board.XSize || ny < 0 || ny >= board.YSize {
+				return nil, fmt.Errorf("placement of puzzle %d is out of the board", p.PuzzleIndex)
+			}
+			pieceAt[ny][nx] = p.PuzzleIndex
+		}
+	}
+	return pieceAt, nil
+}
+
+// RenderBoardASCII draws the board with the given placements applied.
+// Cells are shown as: " . " empty, " X " banned, "[a]" locked block of hue a, " 3a" piece 3 of hue a.
+func RenderBoardASCII(bd *BoardDesc, placements []Placement) (string, error) {
+	board, puzzles, err := prepare(bd)
+	if err != nil {
+		return "", err
+	}
+
+	pieceAt, err := getPieceMap(board, puzzles, placements)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	// 1. Legend
+	sb.WriteString("Hues:")
+	for i, h := range bd.HueList {
+		fmt.Fprintf(&sb, " %c=%d", hueLetter(i), h)
+	}
+	sb.WriteString("\n")
+
+	// 2. Projections (aligned to the board)
+	for i := 0; i < board.K; i++ {
+		fmt.Fprintf(&sb, "%c  X:", hueLetter(i))
+		for _, v := range board.XProj[i] {
+			fmt.Fprintf(&sb, " %d", v)
+		}
+		sb.WriteString("  Y:")
+		for _, v := range board.YProj[i] {
+			fmt.Fprintf(&sb, " %d", v)
+		}
+		sb.WriteString("\n")
+	}
+
+	// 3. Grid
+	border := "+" + strings.Repeat("---", board.XSize) + "+\n"
+	sb.WriteString(border)
+	for y := 0; y < board.YSize; y++ {
+		sb.WriteString("|")
+		for x := 0; x < board.XSize; x++ {
+			switch cell := board.Grid[y][x]; {
+			case pieceAt[y][x] >= 0:
+				pz := puzzles[pieceAt[y][x]]
+				fmt.Fprintf(&sb, "%2d%c", pz.Index, hueLetter(pz.Color))
+			case cell == -2:
+				sb.WriteString(" X ")
+			case cell >= 0:
+				fmt.Fprintf(&sb, "[%c]", hueLetter(cell))
+			default:
+				sb.WriteString(" . ")
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	sb.WriteString(border)
+
+	return sb.String(), nil
+}
+
+// getHueCSS returns the CSS color of a hue, darker for locked blocks
+func getHueCSS(hue int, dark bool) string {
+	if dark {
+		return fmt.Sprintf("hsl(%d, 60%%, 28%%)", hue)
+	}
+	return fmt.Sprintf("hsl(%d, 75%%, 45%%)", hue)
+}
+
+// RenderBoardHTML draws the board with the given placements applied as an HTML table for MXU.
+// Projections are listed per hue above and left of the board, pieces are filled with their hue
+// and labeled with their index, locked blocks are dark and marked, banned cells are crossed.
+func RenderBoardHTML(bd *BoardDesc, placements []Placement) (string, error) {
+	board, puzzles, err := prepare(bd)
+	if err != nil {
+		return "", err
+	}
+	pieceAt, err := getPieceMap(board, puzzles, placements)
+	if err != nil {
+		return "", err
+	}
+
+	const cellStyle = "width: 20px; height: 20px; padding: 0; border: 1px solid #555; text-align: center; font-size: 11px;"
+	projHTML := func(get func(h int) int, sep string) string {
+		parts := make([]string, board.K)
+		for h := range board.K {
+			parts[h] = fmt.Sprintf(`<span style="color: %s; font-weight: bold;">%d</span>`,
+				getHueCSS(bd.HueList[h], false), get(h))
+		}
+		return strings.Join(parts, sep)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<table style="border-collapse: collapse;">`)
+
+	// 1. Column projections
+	sb.WriteString(`<tr><td></td>`)
+	for x := 0; x < board.XSize; x++ {
+		fmt.Fprintf(&sb, `<td style="text-align: center; font-size: 11px; vertical-align: bottom;">%s</td>`,
+			projHTML(func(h int) int { return board.XProj[h][x] }, "<br/>"))
+	}
+	sb.WriteString(`</tr>`)
+
+	// 2. Rows with their projections
+	for y := 0; y < board.YSize; y++ {
+		fmt.Fprintf(&sb, `<tr><td style="text-align: right; font-size: 11px; padding-right: 4px;">%s</td>`,
+			projHTML(func(h int) int { return board.YProj[h][y] }, " "))
+		for x := 0; x < board.XSize; x++ {
+			switch cell := board.Grid[y][x]; {
+			case pieceAt[y][x] >= 0:
+				pz := puzzles[pieceAt[y][x]]
+				fmt.Fprintf(&sb, `<td style="%s background: %s; color: #fff;">%d</td>`,
+					cellStyle, getHueCSS(bd.HueList[pz.Color], false), pz.Index)
+			case cell == -2:
+				fmt.Fprintf(&sb, `<td style="%s background: #333; color: #888;">✕</td>`, cellStyle)
+			case cell >= 0:
+				fmt.Fprintf(&sb, `<td style="%s background: %s; color: #ddd;">■</td>`,
+					cellStyle, getHueCSS(bd.HueList[cell], true))
+			default:
+				fmt.Fprintf(&sb, `<td style="%s"></td>`, cellStyle)
+			}
+		}
+		sb.WriteString(`</tr>`)
+	}
+	sb.WriteString(`</table>`)
+
+	return sb.String(), nil
+}
+
+// RenderSolutionImage annotates a screenshot (in the work space) with the given placements:
+// the outline of every piece on its target cells, and the same outline around its thumbnail
+// if it is on the first page of the panel.
+func RenderSolutionImage(img image.Image, bd *BoardDesc, placements []Placement) (image.Image, error) {
+	board, puzzles, err := prepare(bd)
+	if err != nil {
+		return nil, err
+	}
+	pieceAt, err := getPieceMap(board, puzzles, placements)
+	if err != nil {
+		return nil, err
+	}
+
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	const thickness = 3
+	fill := func(rect image.Rectangle, c color.RGBA) {
+		draw.Draw(out, rect.Intersect(out.Bounds()), &image.Uniform{c}, image.Point{}, draw.Src)
+	}
+	outline := func(rect image.Rectangle, c color.RGBA, top, right, bottom, left bool) {
+		if top {
+			fill(image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+thickness), c)
+		}
+		if bottom {
+			fill(image.Rect(rect.Min.X, rect.Max.Y-thickness, rect.Max.X, rect.Max.Y), c)
+		}
+		if left {
+			fill(image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+thickness, rect.Max.Y), c)
+		}
+		if right {
+			fill(image.Rect(rect.Max.X-thickness, rect.Min.Y, rect.Max.X, rect.Max.Y), c)
+		}
+	}
+	pieceColor := func(idx int) color.RGBA {
+		// Lighter than the game's own blocks, so that outlines stand out on them
+		return hsvToRGB(float64(bd.HueList[puzzles[idx].Color]), 0.6, 1)
+	}
+	other := func(x, y, idx int) bool {
+		return x < 0 || x >= board.XSize || y < 0 || y >= board.YSize || pieceAt[y][x] != idx
+	}
+
+	// 1. Piece outlines on the board, drawn only where the neighbor belongs to another piece
+	for y := 0; y < board.YSize; y++ {
+		for x := 0; x < board.XSize; x++ {
+			idx := pieceAt[y][x]
+			if idx < 0 {
+				continue
+			}
+			ltX, ltY := convertBoardCoordToLTCoord(x, y, bd.W, bd.H)
+			rect := image.Rect(ltX, ltY, ltX+int(BOARD_BLOCK_W), ltY+int(BOARD_BLOCK_H))
+			outline(rect, pieceColor(idx), other(x, y-1, idx), other(x+1, y, idx), other(x, y+1, idx), other(x-1, y, idx))
+		}
+	}
+
+	// 2. Thumbnail outlines
+	for _, p := range placements {
+		if page, slot := bd.getThumbSlot(p.PuzzleIndex); page == 0 {
+			cx, cy := getThumbCenter(slot)
+			rect := image.Rect(cx-int(PUZZLE_THUMB_W)/2, cy-int(PUZZLE_THUMB_H)/2, cx+int(PUZZLE_THUMB_W)/2, cy+int(PUZZLE_THUMB_H)/2)
+			outline(rect, pieceColor(p.PuzzleIndex), true, true, true, true)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var renderBoard = flag.String("render", "", "render the named board of testdata/boards into testdata/screens/rendered_<name>")
+
+// renderScreens draws the screens of a single-hue board into dir, in the fixture layout (see fixtureScreenFile):
+// a projection figure per line with one bar per block, banned and locked blocks, a thumbnail and a preview per puzzle.
+// It only draws what recognition looks at, the result is no substitute for a screen recorded in the game.
+func renderScreens(tb testing.TB, dir string, bd *BoardDesc) {
+	tb.Helper()
+	if len(bd.HueList) != 1 || len(bd.PuzzleList) > PUZZLE_THUMB_MAX_ROWS*PUZZLE_THUMB_MAX_COLS {
+		tb.Fatalf("only single-hue boards on one thumbnail page can be rendered")
+	}
+	load := func(name string) image.Image {
+		img, err := loadPNG(filepath.Join(resourceDir, "image", "PuzzleSolver", name))
+		if err != nil {
+			tb.Fatal(err)
+		}
+		return img
+	}
+	projX, projY, banned := load("ProjX_SVGB.png"), load("ProjY_SVGB.png"), load("BlockBanned.png")
+	hue := bd.HueList[0]
+	background := color.RGBA{30, 30, 30, 255}
+
+	screen := newFilledImage(background)
+	for i, x := range TAB_X_LIST {
+		c := color.RGBA{80, 80, 80, 255}
+		if i == 0 {
+			c = color.RGBA{200, 200, 200, 255}
+		}
+		fillRect(screen, image.Rect(int(x), int(TAB_Y), int(x+TAB_W), int(TAB_Y+TAB_H)), c)
+	}
+	for slot := range bd.PuzzleList {
+		x, y := getThumbCenter(slot)
+		rect := image.Rect(x-int(PUZZLE_THUMB_W)/2, y-int(PUZZLE_THUMB_H)/2, x+int(PUZZLE_THUMB_W)/2, y+int(PUZZLE_THUMB_H)/2)
+		fillChecker(screen, rect, color.RGBA{40, 40, 40, 255}, color.RGBA{100, 100, 100, 255})
+	}
+
+	blockRect := func(loc [2]int) image.Rectangle {
+		ltX, ltY := convertBoardCoordToLTCoord(loc[0], loc[1], bd.W, bd.H)
+		return image.Rect(ltX, ltY, ltX+int(BOARD_BLOCK_W), ltY+int(BOARD_BLOCK_H))
+	}
+	for _, bb := range bd.BannedBlockList {
+		drawImage(screen, blockRect(bb.Loc).Min, banned, nil)
+	}
+	for _, lb := range bd.LockedBlockList[0] {
+		fillRect(screen, blockRect(lb.Loc), hsvToRGB(float64(lb.Hue), 0.8, 0.9))
+	}
+
+	// The bar templates are in SVGB, painted back in the hue. The brightest rows (columns)
+	// of a bar are 3 to 8 (3 to 9), the outermost one ends where the scan expects the bar to end.
+	toHue := func(c color.Color) color.Color {
+		_, s, v, _ := c.RGBA()
+		return hsvToRGB(float64(hue), float64(s>>8)/255, float64(v>>8)/255)
+	}
+	pd := bd.ProjDescList[0]
+	distY := float64(bd.H-1) / 2
+	for x, n := range pd.XProjList {
+		ltX := int(BOARD_CENTER_BLOCK_LT_X + (float64(x)-float64(bd.W-1)/2)*BOARD_BLOCK_W)
+		ltY := int(BOARD_CENTER_BLOCK_LT_Y - distY*BOARD_BLOCK_H - PROJ_X_FIGURE_H)
+		rect, _ := getProjFigureRect(ltX, ltY, "X")
+		for k := 1; k <= n; k++ {
+			far := int(PROJ_INIT_GAP + float64(k)*PROJ_EACH_GAP)
+			at := image.Pt(rect.Min.X+(rect.Dx()-projX.Bounds().Dx())/2, rect.Max.Y-far-3)
+			drawImage(screen, at, projX, toHue)
+		}
+	}
+	distX := float64(bd.W-1) / 2
+	for y, n := range pd.YProjList {
+		ltX := int(BOARD_CENTER_BLOCK_LT_X - distX*BOARD_BLOCK_W - PROJ_Y_FIGURE_W)
+		ltY := int(BOARD_CENTER_BLOCK_LT_Y + (float64(y)-float64(bd.H-1)/2)*BOARD_BLOCK_H)
+		rect, _ := getProjFigureRect(ltX, ltY, "Y")
+		for k := 1; k <= n; k++ {
+			far := int(PROJ_INIT_GAP + float64(k)*PROJ_EACH_GAP)
+			at := image.Pt(rect.Max.X-far-3, rect.Min.Y+(rect.Dy()-projY.Bounds().Dy())/2)
+			drawImage(screen, at, projY, toHue)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		tb.Fatal(err)
+	}
+	if err := savePNG(filepath.Join(dir, fixtureScreenFile), screen); err != nil {
+		tb.Fatal(err)
+	}
+	for i, pz := range bd.PuzzleList {
+		preview := newFilledImage(background)
+		for _, b := range pz.Blocks {
+			cx := PUZZLE_PREVIEW_MV_CENTER_X + float64(b[0])*PUZZLE_W
+			cy := PUZZLE_PREVIEW_MV_CENTER_Y + float64(b[1])*PUZZLE_H
+			x1, y1 := int(math.Round(cx-PUZZLE_W/2)), int(math.Round(cy-PUZZLE_H/2))
+			fillChecker(preview, image.Rect(x1, y1, x1+int(PUZZLE_W), y1+int(PUZZLE_H)),
+				hsvToRGB(float64(pz.Hue), 0.85, 0.75), hsvToRGB(float64(pz.Hue), 0.65, 1))
+		}
+		if err := savePNG(filepath.Join(dir, fmt.Sprintf(fixturePreviewFile, i)), preview); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawImage copies src onto img with its top left corner at pt, converting each pixel if conv is set
+func drawImage(img *image.RGBA, pt image.Point, src image.Image, conv func(color.Color) color.Color) {
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := src.At(x, y)
+			if conv != nil {
+				c = conv(c)
+			}
+			img.Set(pt.X+x-b.Min.X, pt.Y+y-b.Min.Y, c)
+		}
+	}
+}
+
+// TestRenderScreens writes the board named by -render into testdata/screens,
+// run TestRecognizeRecordedScreens with -update afterwards to write its golden.json
+func TestRenderScreens(t *testing.T) {
+	if *renderBoard == "" {
+		t.Skip("no board to render, see -render")
+	}
+	bd, err := LoadBoardDesc(filepath.Join("testdata", "boards", *renderBoard+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderScreens(t, filepath.Join("testdata", "screens", "rendered_"+*renderBoard), bd)
+}
+
+// TestRecognizeRenderedBoards renders every single-hue fixture board and reads it back
+func TestRecognizeRenderedBoards(t *testing.T) {
+	for name, want := range loadFixtureBoards(t) {
+		if len(want.HueList) != 1 || len(want.PuzzleList) > PUZZLE_THUMB_MAX_ROWS*PUZZLE_THUMB_MAX_COLS {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			renderScreens(t, dir, want)
+			got, err := RecognizeFixture(dir, resourceDir)
+			if err != nil {
+				t.Fatalf("recognize: %v", err)
+			}
+			checkRenderedBoard(t, got, want)
+			if got.Confidence != 1 {
+				t.Errorf("confidence %v, want 1", got.Confidence)
+			}
+		})
+	}
+}
+
+// TestRenderedGoldensMatchBoards checks each rendered_<name> golden.json against testdata/boards/<name>.json,
+// so a golden rewritten by -update cannot take in a recognition regression unnoticed
+func TestRenderedGoldensMatchBoards(t *testing.T) {
+	for _, dir := range screenFixtureDirs(t) {
+		name, ok := strings.CutPrefix(filepath.Base(dir), "rendered_")
+		if !ok {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			want, err := LoadBoardDesc(filepath.Join("testdata", "boards", name+".json"))
+			if err != nil {
+				t.Fatalf("load board: %v", err)
+			}
+			got, err := LoadBoardDesc(filepath.Join(dir, fixtureGoldenFile))
+			if err != nil {
+				t.Fatalf("load golden: %v", err)
+			}
+			checkRenderedBoard(t, got, want)
+		})
+	}
+}
+
+// checkRenderedBoard compares what recognition can read back from a rendered board with the board itself
+func checkRenderedBoard(t *testing.T, got, want *BoardDesc) {
+	t.Helper()
+	if got.W != want.W || got.H != want.H || !reflect.DeepEqual(got.ProjDescList, want.ProjDescList) {
+		t.Fatalf("board %dx%d %+v, want %dx%d %+v", got.W, got.H, got.ProjDescList, want.W, want.H, want.ProjDescList)
+	}
+	if g, w := blockLocs(got.BannedBlockList), blockLocs(want.BannedBlockList); !reflect.DeepEqual(g, w) {
+		t.Errorf("banned blocks %v, want %v", g, w)
+	}
+	if len(got.LockedBlockList) != 1 || len(got.LockedBlockList[0]) != len(want.LockedBlockList[0]) {
+		t.Errorf("locked blocks %v, want %v", got.LockedBlockList, want.LockedBlockList)
+	}
+	if len(got.PuzzleList) != len(want.PuzzleList) {
+		t.Fatalf("%d puzzles, want %d", len(got.PuzzleList), len(want.PuzzleList))
+	}
+	for i := range got.PuzzleList {
+		if !reflect.DeepEqual(got.PuzzleList[i].Blocks, want.PuzzleList[i].Blocks) {
+			t.Errorf("puzzle %d blocks %v, want %v", i, got.PuzzleList[i].Blocks, want.PuzzleList[i].Blocks)
+		}
+	}
+}
+
+func blockLocs(blocks []*BannedBlockDesc) map[[2]int]bool {
+	locs := make(map[[2]int]bool, len(blocks))
+	for _, b := range blocks {
+		locs[b.Loc] = true
+	}
+	return locs
+}
@@ -2,16 +2,22 @@
 package puzzle
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 // Placement represents a settled position for one puzzle piece
 type Placement struct {
-	MachineX    int // X coordinate (grid index)
-	MachineY    int // Y coordinate (grid index)
-	Rotation    int // 0, 1, 2, 3 (CCW * 90)
-	PuzzleIndex int // Index of the puzzle in the input list, can ignore since output is in order
+	MachineX    int `json:"machineX"`    // X coordinate (grid index)
+	MachineY    int `json:"machineY"`    // Y coordinate (grid index)
+	Rotation    int `json:"rotation"`    // 0, 1, 2, 3 (CCW * 90)
+	PuzzleIndex int `json:"puzzleIndex"` // Index of the puzzle in the input list, can ignore since output is in order
 }
 
 type Puzzle struct {
@@ -50,6 +56,96 @@ func (p *Puzzle) getAllDerivatives() []*Puzzle {
 	return drv
 }
 
+// rotationPressOrder lists rotations by the number of R presses doPlace needs, fewest first
+var rotationPressOrder = [4]int{0, 3, 2, 1}
+
+// normalizedKey returns a key of the blocks translated to the origin, independent of block order
+func normalizedKey(blocks [][2]int) string {
+	if len(blocks) == 0 {
+		return ""
+	}
+	minX, minY := blocks[0][0], blocks[0][1]
+	for _, b := range blocks {
+		minX = min(minX, b[0])
+		minY = min(minY, b[1])
+	}
+	norm := make([][2]int, len(blocks))
+	for i, b := range blocks {
+		norm[i] = [2]int{b[0] - minX, b[1] - minY}
+	}
+	sort.Slice(norm, func(i, j int) bool {
+		if norm[i][1] != norm[j][1] {
+			return norm[i][1] < norm[j][1]
+		}
+		return norm[i][0] < norm[j][0]
+	})
+	key := make([]byte, 0, 2*len(norm))
+	for _, b := range norm {
+		key = append(key, byte(b[0]), byte(b[1]))
+	}
+	return string(key)
+}
+
+// getUniqueDerivatives returns the rotations of the puzzle that cover distinct shapes.
+// Among rotations with the same shape, the one needing fewer R presses is kept.
+func (p *Puzzle) getUniqueDerivatives() []*Puzzle {
+	all := p.getAllDerivatives()
+	seen := make(map[string]bool, 4)
+	drv := make([]*Puzzle, 0, 4)
+	for _, r := range rotationPressOrder {
+		key := normalizedKey(all[r].Blocks)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		drv = append(drv, all[r])
+	}
+	return drv
+}
+
+// getShapeKey identifies the shape of the puzzle regardless of its rotation and position
+func (p *Puzzle) getShapeKey() string {
+	best := ""
+	for i, d := range p.getAllDerivatives() {
+		key := normalizedKey(d.Blocks)
+		if i == 0 || key < best {
+			best = key
+		}
+	}
+	return best
+}
+
+// getIdenticalPrev returns, for each puzzle, the index of the previous puzzle
+// with the same color and shape, or -1 if there is none.
+// Identical puzzles are interchangeable, so the solver fixes their order on the board.
+func getIdenticalPrev(puzzles []*Puzzle) []int {
+	prev := make([]int, len(puzzles))
+	last := make(map[string]int)
+	for i, p := range puzzles {
+		key := fmt.Sprintf("%d:%s", p.Color, p.getShapeKey())
+		if j, ok := last[key]; ok {
+			prev[i] = j
+		} else {
+			prev[i] = -1
+		}
+		last[key] = i
+	}
+	return prev
+}
+
+// getFirstCell returns the row-major index of the first cell covered by the puzzle at (cx, cy).
+// Identical puzzles never overlap, so this orders their placements strictly.
+func (p *Puzzle) getFirstCell(cx, cy, xSize int) int {
+	first := -1
+	for _, b := range p.Blocks {
+		idx := (cy+b[1])*xSize + cx + b[0]
+		if first < 0 || idx < first {
+			first = idx
+		}
+	}
+	return first
+}
+
 func (p *Puzzle) convertFromPuzzleDesc(i int, pd *PuzzleDesc, hueMap map[int]int) {
 	p.Index = i
 	// Find color index from HueList
@@ -66,6 +162,9 @@ func (p *Puzzle) convertFromPuzzleDesc(i int, pd *PuzzleDesc, hueMap map[int]int
 				bestIdx = idx
 			}
 		}
+		if minDiff > PUZZLE_HUE_DIFF_GRT {
+			log.Warn().Int("puzzle", i).Int("hue", pd.Hue).Int("diff", minDiff).Msg("Puzzle hue matches no board hue, using the nearest one")
+		}
 		p.Color = bestIdx
 	}
 	p.Blocks = pd.Blocks
@@ -95,12 +194,18 @@ func (b *Board) convertFromBoardDesc(bd *BoardDesc) error {
 	// 2. Initialize Projections
 	// Map ProjDescList (by hue index) to XProj/YProj
 	// Align projections to the center.
+	if len(bd.ProjDescList) > b.K || len(bd.LockedBlockList) > b.K {
+		return errors.New("more projections or locked block groups than hues in BoardDesc")
+	}
 	b.XProj = make([][]int, b.K)
 	b.YProj = make([][]int, b.K)
+	for i := range b.K {
+		b.XProj[i] = make([]int, b.XSize)
+		b.YProj[i] = make([]int, b.YSize)
+	}
 
 	for i, pd := range bd.ProjDescList {
 		// X Project
-		b.XProj[i] = make([]int, b.XSize)
 		projW := len(pd.XProjList)
 		shiftX := (b.XSize - projW) / 2
 		for j, val := range pd.XProjList {
@@ -111,7 +216,6 @@ func (b *Board) convertFromBoardDesc(bd *BoardDesc) error {
 		}
 
 		// Y Project
-		b.YProj[i] = make([]int, b.YSize)
 		projH := len(pd.YProjList)
 		shiftY := (b.YSize - projH) / 2
 		for j, val := range pd.YProjList {
@@ -222,84 +326,154 @@ func (b *Board) remove(p *Puzzle, cx, cy int) {
 	}
 }
 
-func (b *Board) solveWith(puzzles []*Puzzle) ([]Placement, bool) {
-	// Sort puzzles by size (descending)
-	type IndexedPuzzle struct {
-		OriginalIndex int
-		Pz            *Puzzle
+// backtracker is the state of a depth-first search in puzzle size order
+type backtracker struct {
+	board         *Board
+	puzzles       []*Puzzle
+	order         []int       // Original puzzle indices, largest puzzle first
+	derivatives   [][]*Puzzle // Unique derivatives per puzzle
+	identicalPrev []int       // See getIdenticalPrev
+	firstCells    []int       // First covered cell of each placed puzzle
+	solution      []Placement // Placement of each placed puzzle, by original index
+	monitor       *searchMonitor
+	visit         func([]Placement) bool
+}
+
+func newBacktracker(b *Board, puzzles []*Puzzle, m *searchMonitor, visit func([]Placement) bool) *backtracker {
+	order := make([]int, len(puzzles))
+	for i := range order {
+		order[i] = i
 	}
-	indexed := make([]IndexedPuzzle, len(puzzles))
+	// Stable sort keeps identical puzzles in their original order
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(puzzles[order[i]].Blocks) > len(puzzles[order[j]].Blocks)
+	})
+
+	derivatives := make([][]*Puzzle, len(puzzles))
 	for i, p := range puzzles {
-		indexed[i] = IndexedPuzzle{OriginalIndex: i, Pz: p}
+		derivatives[i] = p.getUniqueDerivatives()
 	}
-	sort.Slice(indexed, func(i, j int) bool {
-		return len(indexed[i].Pz.Blocks) > len(indexed[j].Pz.Blocks)
-	})
+	return &backtracker{
+		board:         b,
+		puzzles:       puzzles,
+		order:         order,
+		derivatives:   derivatives,
+		identicalPrev: getIdenticalPrev(puzzles),
+		firstCells:    make([]int, len(puzzles)),
+		solution:      make([]Placement, len(puzzles)),
+		monitor:       m,
+		visit:         visit,
+	}
+}
 
-	solutionMap := make(map[int]Placement)
+// fork returns a backtracker sharing the static data, with its own copy of the board and placements
+func (s *backtracker) fork(m *searchMonitor, visit func([]Placement) bool) *backtracker {
+	t := *s
+	t.board = s.board.clone()
+	t.firstCells = slices.Clone(s.firstCells)
+	t.solution = slices.Clone(s.solution)
+	t.monitor = m
+	t.visit = visit
+	return &t
+}
 
-	var backtrack func(idx int) bool
-	backtrack = func(idxInSorted int) bool {
-		if idxInSorted == len(indexed) {
-			return true
-		}
+// candidates calls fn with every placement of the depth-th puzzle in order that fits the board,
+// in search order, until fn returns true
+func (s *backtracker) candidates(depth int, fn func(deriv *Puzzle, x, y, first int) bool) bool {
+	b := s.board
+	originalIdx := s.order[depth]
 
-		currentItem := indexed[idxInSorted]
-		originalIdx := currentItem.OriginalIndex
-		rawPuzzle := currentItem.Pz
-		derivatives := rawPuzzle.getAllDerivatives()
+	// An identical puzzle placed before bounds where this one may go
+	minFirst := -1
+	if prev := s.identicalPrev[originalIdx]; prev >= 0 {
+		minFirst = s.firstCells[prev]
+	}
 
-		// Try to place core block at every empty cell
-		for y := 0; y < b.YSize; y++ {
-			for x := 0; x < b.XSize; x++ {
-				if b.Grid[y][x] != -1 {
+	// Try to place core block at every empty cell
+	for y := 0; y < b.YSize; y++ {
+		for x := 0; x < b.XSize; x++ {
+			if b.Grid[y][x] != -1 {
+				continue
+			}
+			for _, deriv := range s.derivatives[originalIdx] {
+				first := deriv.getFirstCell(x, y, b.XSize)
+				if first <= minFirst {
 					continue
 				}
-
-				for _, deriv := range derivatives {
-					if b.canPlace(deriv, x, y) {
-						b.place(deriv, x, y)
-						solutionMap[originalIdx] = Placement{
-							MachineX:    x,
-							MachineY:    y,
-							Rotation:    deriv.Rotation,
-							PuzzleIndex: originalIdx,
-						}
-
-						if backtrack(idxInSorted + 1) {
-							return true
-						}
-
-						b.remove(deriv, x, y)
-						delete(solutionMap, originalIdx)
-					}
+				if b.canPlace(deriv, x, y) && fn(deriv, x, y, first) {
+					return true
 				}
 			}
 		}
-		return false
 	}
+	return false
+}
 
-	if backtrack(0) {
-		result := make([]Placement, len(puzzles))
-		for i := range puzzles {
-			result[i] = solutionMap[i]
-		}
-		return result, true
+// apply places the depth-th puzzle in order
+func (s *backtracker) apply(depth int, deriv *Puzzle, x, y, first int) {
+	originalIdx := s.order[depth]
+	s.board.place(deriv, x, y)
+	s.firstCells[originalIdx] = first
+	s.solution[originalIdx] = Placement{
+		MachineX:    x,
+		MachineY:    y,
+		Rotation:    deriv.Rotation,
+		PuzzleIndex: originalIdx,
+	}
+}
+
+// search places the puzzles from the depth-th in order on, and reports whether visit stopped the search
+func (s *backtracker) search(depth int) bool {
+	if !s.monitor.enter(depth) {
+		return false
+	}
+	if depth == len(s.order) {
+		return s.visit(slices.Clone(s.solution))
 	}
+	return s.candidates(depth, func(deriv *Puzzle, x, y, first int) bool {
+		s.apply(depth, deriv, x, y, first)
+		if s.search(depth + 1) {
+			return true
+		}
+		s.board.remove(deriv, x, y)
+		return false
+	})
+}
 
-	return nil, false
+// solveWith searches placements depth-first in puzzle size order.
+// Each solution is passed to visit, which returns true to stop the search.
+// It reports whether the search was stopped by visit.
+func (b *Board) solveWith(puzzles []*Puzzle, m *searchMonitor, visit func([]Placement) bool) bool {
+	return newBacktracker(b, puzzles, m, visit).search(0)
 }
 
-// Solve calculates the placements to solve the puzzle based on the input state.
-func Solve(bd *BoardDesc) ([]Placement, error) {
+// clone returns a copy of the board that can be searched independently
+func (b *Board) clone() *Board {
+	c := *b
+	c.Grid = make([][]int, len(b.Grid))
+	for y := range b.Grid {
+		c.Grid[y] = slices.Clone(b.Grid[y])
+	}
+	c.CurrXCounts = make([][]int, len(b.CurrXCounts))
+	for h := range b.CurrXCounts {
+		c.CurrXCounts[h] = slices.Clone(b.CurrXCounts[h])
+	}
+	c.CurrYCounts = make([][]int, len(b.CurrYCounts))
+	for h := range b.CurrYCounts {
+		c.CurrYCounts[h] = slices.Clone(b.CurrYCounts[h])
+	}
+	return &c
+}
+
+// prepare converts the board description into the solver's board and puzzle representations.
+func prepare(bd *BoardDesc) (*Board, []*Puzzle, error) {
 	if len(bd.HueList) == 0 {
-		return nil, errors.New("no hues found in board desc")
+		return nil, nil, errors.New("no hues found in board desc")
 	}
 
-	// Prepare data
 	board := &Board{}
 	if err := board.convertFromBoardDesc(bd); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	hueMap := make(map[int]int)
@@ -313,10 +487,191 @@ func Solve(bd *BoardDesc) ([]Placement, error) {
 		pz.convertFromPuzzleDesc(i, pd, hueMap)
 		puzzles[i] = pz
 	}
+	return board, puzzles, nil
+}
+
+// Engine selects the search algorithm used by Solve
+type Engine string
+
+const (
+	EngineBacktrack Engine = "backtrack" // Depth-first search in puzzle size order (Board.solveWith), the default
+	// Most-constrained-first search with projection pruning (Board.solvePropagate). Opt-in: unlike the
+	// backtracker it requires every projection to be met exactly, which is yet to be checked on real boards.
+	EnginePropagate Engine = "propagate"
+)
+
+// SolveOptions controls how Solve searches for placements
+type SolveOptions struct {
+	Engine           Engine              // Defaults to EngineBacktrack
+	NodeBudget       int                 // Maximum search nodes to explore, 0 means unlimited
+	Progress         func(SolveProgress) // Called periodically during the search, may be nil
+	ProgressInterval time.Duration       // Minimum interval between progress reports, defaults to 1s
+	Workers          int                 // Parallel workers for EngineBacktrack, 0 or 1 searches sequentially
+	Seed             int64               // Order of the branches searched in parallel, 0 keeps the sequential order
+
+	// Used by SolveAll only
+	MaxSolutions    int           // Maximum solutions to enumerate, defaults to 16
+	ExtraSearchTime time.Duration // How long to keep enumerating after the first solution, defaults to 2s
+}
+
+// SolveResult holds the enumerated solutions of a puzzle
+type SolveResult struct {
+	Solutions  [][]Placement // Cheapest first
+	Costs      []float64     // Estimated input cost of each solution, see SolutionCost
+	Exhaustive bool          // Whether the search space was fully explored, i.e. there are no other solutions
+}
+
+// Solve calculates the placements to solve the puzzle based on the input state.
+// The search stops with ctx.Err() once ctx is done.
+func Solve(ctx context.Context, bd *BoardDesc) ([]Placement, error) {
+	return SolveWithOptions(ctx, bd, SolveOptions{})
+}
+
+// SolveWithOptions is like Solve but allows choosing the search engine, node budget and progress reporting.
+func SolveWithOptions(ctx context.Context, bd *BoardDesc, opts SolveOptions) ([]Placement, error) {
+	// Prepare data
+	board, puzzles, err := prepare(bd)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newSearchMonitor(ctx, opts)
+	var result []Placement
+	err = runEngine(board, puzzles, opts, 1, m, func(p []Placement) bool {
+		result = p
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	if result == nil {
+		return nil, ErrNoSolution
+	}
+	return result, nil
+}
+
+// SolveAll enumerates up to opts.MaxSolutions solutions and sorts them by estimated input cost.
+// If the search is stopped after a solution was found, the solutions found so far are returned.
+func SolveAll(ctx context.Context, bd *BoardDesc, opts SolveOptions) (*SolveResult, error) {
+	board, puzzles, err := prepare(bd)
+	if err != nil {
+		return nil, err
+	}
+	maxSolutions := opts.MaxSolutions
+	if maxSolutions <= 0 {
+		maxSolutions = 16
+	}
+	extraTime := opts.ExtraSearchTime
+	if extraTime <= 0 {
+		extraTime = 2 * time.Second
+	}
 
-	result, ok := board.solveWith(puzzles)
-	if !ok {
-		return nil, errors.New("no solution found")
+	m := newSearchMonitor(ctx, opts)
+	var solutions [][]Placement
+	stopped := false
+	err = runEngine(board, puzzles, opts, maxSolutions, m, func(p []Placement) bool {
+		solutions = append(solutions, optimizePlacements(bd, puzzles, p))
+		if len(solutions) == 1 {
+			// The monitor stops the search at the deadline even if no other solution turns up
+			m.setDeadline(time.Now().Add(extraTime))
+		}
+		stopped = len(solutions) >= maxSolutions
+		return stopped
+	})
+	if err != nil {
+		return nil, err
+	}
+	if m.err == errSearchDeadline {
+		m.err, stopped = nil, true
+	}
+	if len(solutions) == 0 {
+		if m.err != nil {
+			return nil, m.err
+		}
+		return nil, ErrNoSolution
+	}
+
+	result := &SolveResult{
+		Solutions:  solutions,
+		Costs:      make([]float64, len(solutions)),
+		Exhaustive: !stopped && m.err == nil,
+	}
+	for i, s := range solutions {
+		result.Costs[i] = SolutionCost(bd, s)
 	}
+	sort.Stable(result)
 	return result, nil
 }
+
+func (r *SolveResult) Len() int           { return len(r.Solutions) }
+func (r *SolveResult) Less(i, j int) bool { return r.Costs[i] < r.Costs[j] }
+func (r *SolveResult) Swap(i, j int) {
+	r.Solutions[i], r.Solutions[j] = r.Solutions[j], r.Solutions[i]
+	r.Costs[i], r.Costs[j] = r.Costs[j], r.Costs[i]
+}
+
+// runEngine runs the chosen engine, passing each solution to visit until it returns true.
+// limit is the most solutions visit may ask for, which bounds the work of parallel workers.
+func runEngine(board *Board, puzzles []*Puzzle, opts SolveOptions, limit int, m *searchMonitor, visit func([]Placement) bool) error {
+	switch engine := opts.Engine; engine {
+	case EngineBacktrack, "":
+		if opts.Workers > 1 {
+			board.solveParallel(puzzles, m, opts.Workers, opts.Seed, limit, visit)
+		} else {
+			board.solveWith(puzzles, m, visit)
+		}
+	case EnginePropagate:
+		if opts.Workers > 1 {
+			log.Warn().Int("workers", opts.Workers).Msg("Parallel workers only apply to the backtrack engine, searching sequentially")
+		}
+		board.solvePropagate(puzzles, m, visit)
+	default:
+		return fmt.Errorf("unknown solver engine %q", engine)
+	}
+	return nil
+}
+
+// VerifyPlacements checks that the placements put every puzzle on free cells
+// and meet every projection exactly.
+func VerifyPlacements(bd *BoardDesc, placements []Placement) error {
+	board, puzzles, err := prepare(bd)
+	if err != nil {
+		return err
+	}
+	if len(placements) != len(puzzles) {
+		return fmt.Errorf("expected %d placements, got %d", len(puzzles), len(placements))
+	}
+
+	seen := make([]bool, len(puzzles))
+	for _, p := range placements {
+		if p.PuzzleIndex < 0 || p.PuzzleIndex >= len(puzzles) || seen[p.PuzzleIndex] {
+			return fmt.Errorf("invalid or duplicated puzzle index %d", p.PuzzleIndex)
+		}
+		seen[p.PuzzleIndex] = true
+		deriv := puzzles[p.PuzzleIndex].getAllDerivatives()[((p.Rotation%4)+4)%4]
+		for _, block := range deriv.Blocks {
+			nx, ny := p.MachineX+block[0], p.MachineY+block[1]
+			if nx < 0 || nx >= board.XSize || ny < 0 || ny >= board.YSize || board.Grid[ny][nx] != -1 {
+				return fmt.Errorf("puzzle %d does not fit at (%d, %d)", p.PuzzleIndex, p.MachineX, p.MachineY)
+			}
+		}
+		board.place(deriv, p.MachineX, p.MachineY)
+	}
+
+	for h := 0; h < board.K; h++ {
+		for x := 0; x < board.XSize; x++ {
+			if board.CurrXCounts[h][x] != board.XProj[h][x] {
+				return fmt.Errorf("hue %d column %d expects %d, got %d", bd.HueList[h], x, board.XProj[h][x], board.CurrXCounts[h][x])
+			}
+		}
+		for y := 0; y < board.YSize; y++ {
+			if board.CurrYCounts[h][y] != board.YProj[h][y] {
+				return fmt.Errorf("hue %d row %d expects %d, got %d", bd.HueList[h], y, board.YProj[h][y], board.CurrYCounts[h][y])
+			}
+		}
+	}
+	return nil
+}
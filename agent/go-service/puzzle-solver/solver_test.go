@@ -0,0 +1,203 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var engines = []Engine{EngineBacktrack, EnginePropagate}
+
+// loadFixtureBoards loads every BoardDesc under testdata/boards.
+// These are hand-made boards, not dumps of recognized game boards, see testdata/boards/README.md.
+func loadFixtureBoards(tb testing.TB) map[string]*BoardDesc {
+	tb.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "boards", "*.json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(paths) == 0 {
+		tb.Fatal("no fixture boards found in testdata/boards")
+	}
+
+	boards := make(map[string]*BoardDesc, len(paths))
+	for _, path := range paths {
+		bd, err := LoadBoardDesc(path)
+		if err != nil {
+			tb.Fatalf("load %s: %v", path, err)
+		}
+		boards[strings.TrimSuffix(filepath.Base(path), ".json")] = bd
+	}
+	return boards
+}
+
+func TestEnginesSolveFixtureBoards(t *testing.T) {
+	for name, bd := range loadFixtureBoards(t) {
+		for _, engine := range engines {
+			t.Run(name+"/"+string(engine), func(t *testing.T) {
+				placements, err := SolveWithOptions(context.Background(), bd, SolveOptions{Engine: engine})
+				if err != nil {
+					t.Fatalf("solve: %v", err)
+				}
+				if err := VerifyPlacements(bd, placements); err != nil {
+					t.Fatalf("verify: %v", err)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkEngines(b *testing.B) {
+	for name, bd := range loadFixtureBoards(b) {
+		for _, engine := range engines {
+			b.Run(name+"/"+string(engine), func(b *testing.B) {
+				for b.Loop() {
+					if _, err := SolveWithOptions(context.Background(), bd, SolveOptions{Engine: engine}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestUniqueDerivatives(t *testing.T) {
+	cases := []struct {
+		name      string
+		blocks    [][2]int
+		rotations []int
+	}{
+		{"O", [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}}, []int{0}},
+		{"I", [][2]int{{0, 0}, {1, 0}, {2, 0}}, []int{0, 3}},
+		{"T", [][2]int{{0, 0}, {-1, 0}, {1, 0}, {0, 1}}, []int{0, 3, 2, 1}},
+		{"single", [][2]int{{0, 0}}, []int{0}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pz := &Puzzle{Blocks: c.blocks}
+			drv := pz.getUniqueDerivatives()
+			if len(drv) != len(c.rotations) {
+				t.Fatalf("expected %d derivatives, got %d", len(c.rotations), len(drv))
+			}
+			for i, d := range drv {
+				if d.Rotation != c.rotations[i] {
+					t.Errorf("derivative %d: expected rotation %d, got %d", i, c.rotations[i], d.Rotation)
+				}
+			}
+		})
+	}
+}
+
+func TestIdenticalPrev(t *testing.T) {
+	puzzles := []*Puzzle{
+		{Color: 0, Blocks: [][2]int{{0, 0}, {1, 0}}},
+		{Color: 1, Blocks: [][2]int{{0, 0}, {1, 0}}},
+		{Color: 0, Blocks: [][2]int{{0, 0}, {0, -1}}}, // Same as puzzle 0 rotated
+		{Color: 0, Blocks: [][2]int{{0, 0}}},
+	}
+	got := getIdenticalPrev(puzzles)
+	want := []int{-1, -1, 0, -1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestSolveStopsOnBudgetAndCancel(t *testing.T) {
+	bd := loadFixtureBoards(t)["large_7x7_1hue"]
+	for _, engine := range engines {
+		t.Run(string(engine), func(t *testing.T) {
+			_, err := SolveWithOptions(context.Background(), bd, SolveOptions{Engine: engine, NodeBudget: 3})
+			if !errors.Is(err, ErrNodeBudgetExceeded) {
+				t.Errorf("expected budget error, got %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err = SolveWithOptions(ctx, bd, SolveOptions{Engine: engine})
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected cancellation, got %v", err)
+			}
+		})
+	}
+}
+
+// TestSolveAllExtraSearchTime checks that the search stops once ExtraSearchTime has passed after the
+// first solution, even though no other solution turns up to notice it
+func TestSolveAllExtraSearchTime(t *testing.T) {
+	// A board with sparse solutions: 13 of them in the first 1.5s, and more search beyond
+	bd, _ := GenerateBoard(rand.New(rand.NewSource(28)), GeneratePresets["hard"])
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := Solve(ctx, bd); err != nil {
+		t.Fatal(err)
+	}
+	first := time.Since(start)
+
+	const extra = 20 * time.Millisecond
+	start = time.Now()
+	result, err := SolveAll(ctx, bd, SolveOptions{MaxSolutions: 1 << 20, ExtraSearchTime: extra})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*first+extra+time.Second {
+		t.Errorf("searched for %v, the first solution took %v", elapsed, first)
+	}
+	if result.Exhaustive {
+		t.Error("stopped search reported as exhaustive")
+	}
+}
+
+func TestSearchMonitorDeadline(t *testing.T) {
+	m := newSearchMonitor(context.Background(), SolveOptions{})
+	if !m.enter(0) {
+		t.Fatal("stopped without a deadline")
+	}
+	m.setDeadline(time.Now().Add(-time.Second))
+	for i := 0; i < monitorCheckEvery && m.enter(1); i++ {
+	}
+	if m.err != errSearchDeadline {
+		t.Errorf("stopped with %v, want the deadline", m.err)
+	}
+
+	// Parallel workers see the deadline of the parent
+	m = newSearchMonitor(context.Background(), SolveOptions{})
+	m.setDeadline(time.Now().Add(-time.Second))
+	if f := m.fork(context.Background()); f.enter(1) {
+		t.Error("fork went on past the deadline of its parent")
+	}
+}
+
+func TestSolveAllSortsVerifiedSolutionsByCost(t *testing.T) {
+	for name, bd := range loadFixtureBoards(t) {
+		t.Run(name, func(t *testing.T) {
+			result, err := SolveAll(context.Background(), bd, SolveOptions{MaxSolutions: 8})
+			if err != nil {
+				t.Fatalf("solve: %v", err)
+			}
+			for i, s := range result.Solutions {
+				if err := VerifyPlacements(bd, s); err != nil {
+					t.Fatalf("solution %d: %v", i, err)
+				}
+				if i > 0 && result.Costs[i] < result.Costs[i-1] {
+					t.Fatalf("solutions not sorted by cost: %v", result.Costs)
+				}
+			}
+			first, err := Solve(context.Background(), bd)
+			if err != nil {
+				t.Fatalf("solve first: %v", err)
+			}
+			if result.Costs[0] > SolutionCost(bd, first)+1e-9 {
+				t.Errorf("cheapest cost %f is above the first solution's %f", result.Costs[0], SolutionCost(bd, first))
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+const (
+	templateCoarseMinArea = 256  // Templates at least this large are searched on a half-size image first
+	templateCoarseSlack   = 0.15 // Coarse scores may be this much lower than the threshold and still be refined
+)
+
+// rgbPlanes holds an image region as float channels, row-major
+type rgbPlanes struct {
+	w, h int
+	c    [3][]float64
+}
+
+func newRGBPlanes(img image.Image, rect image.Rectangle) *rgbPlanes {
+	rect = rect.Intersect(img.Bounds())
+	p := &rgbPlanes{w: rect.Dx(), h: rect.Dy()}
+	for k := range p.c {
+		p.c[k] = make([]float64, p.w*p.h)
+	}
+	if rgba, ok := img.(*image.RGBA); ok {
+		// Fast path for the usual screenshot format
+		for y := 0; y < p.h; y++ {
+			off := rgba.PixOffset(rect.Min.X, rect.Min.Y+y)
+			for x := 0; x < p.w; x++ {
+				i := y*p.w + x
+				p.c[0][i] = float64(rgba.Pix[off+4*x])
+				p.c[1][i] = float64(rgba.Pix[off+4*x+1])
+				p.c[2][i] = float64(rgba.Pix[off+4*x+2])
+			}
+		}
+		return p
+	}
+	for y := 0; y < p.h; y++ {
+		for x := 0; x < p.w; x++ {
+			r, g, b, _ := img.At(rect.Min.X+x, rect.Min.Y+y).RGBA()
+			i := y*p.w + x
+			p.c[0][i] = float64(r >> 8)
+			p.c[1][i] = float64(g >> 8)
+			p.c[2][i] = float64(b >> 8)
+		}
+	}
+	return p
+}
+
+// halve returns the planes downscaled by 2 with box filtering
+func (p *rgbPlanes) halve() *rgbPlanes {
+	q := &rgbPlanes{w: p.w / 2, h: p.h / 2}
+	for k := range q.c {
+		q.c[k] = make([]float64, q.w*q.h)
+		for y := 0; y < q.h; y++ {
+			for x := 0; x < q.w; x++ {
+				i := 2*y*p.w + 2*x
+				q.c[k][y*q.w+x] = (p.c[k][i] + p.c[k][i+1] + p.c[k][i+p.w] + p.c[k][i+p.w+1]) / 4
+			}
+		}
+	}
+	return q
+}
+
+// template is a template prepared for TM_CCOEFF_NORMED: each channel has its mean removed
+type template struct {
+	w, h int
+	c    [3][]float64
+	norm float64 // Sum of squares of all centered channels
+}
+
+func newTemplate(p *rgbPlanes) *template {
+	t := &template{w: p.w, h: p.h}
+	n := float64(p.w * p.h)
+	for k := range t.c {
+		mean := 0.0
+		for _, v := range p.c[k] {
+			mean += v
+		}
+		mean /= n
+		t.c[k] = make([]float64, len(p.c[k]))
+		for i, v := range p.c[k] {
+			t.c[k][i] = v - mean
+			t.norm += t.c[k][i] * t.c[k][i]
+		}
+	}
+	return t
+}
+
+// score computes TM_CCOEFF_NORMED of the template at (x, y) of src, summed over channels like OpenCV
+func (t *template) score(src *rgbPlanes, x, y int) float64 {
+	n := float64(t.w * t.h)
+	cross, sqSum := 0.0, 0.0
+	for k := range t.c {
+		sum, sq, dot := 0.0, 0.0, 0.0
+		for ty := 0; ty < t.h; ty++ {
+			row := src.c[k][(y+ty)*src.w+x : (y+ty)*src.w+x+t.w]
+			tRow := t.c[k][ty*t.w : (ty+1)*t.w]
+			for tx, v := range row {
+				sum += v
+				sq += v * v
+				dot += v * tRow[tx]
+			}
+		}
+		// The template is centered, so the window mean does not change the dot product
+		cross += dot
+		sqSum += sq - sum*sum/n
+	}
+	denom := math.Sqrt(sqSum * t.norm)
+	if denom < 1e-9 {
+		return 0
+	}
+	return cross / denom
+}
+
+// matchTemplateImage is the pure Go counterpart of matchTemplateAll, used when no maa.Context is available.
+// It scores every position in roi with TM_CCOEFF_NORMED and keeps non-overlapping matches above threshold.
+func matchTemplateImage(img, tplImg image.Image, roi []int, threshold float64, maxMatch int) []TemplateMatchDTO {
+	rect := img.Bounds()
+	if len(roi) >= 4 {
+		rect = image.Rect(roi[0], roi[1], roi[0]+roi[2], roi[1]+roi[3]).Intersect(rect)
+	}
+	src := newRGBPlanes(img, rect)
+	tplPlanes := newRGBPlanes(tplImg, tplImg.Bounds())
+	tpl := newTemplate(tplPlanes)
+	if tpl.w == 0 || tpl.h == 0 || src.w < tpl.w || src.h < tpl.h {
+		return make([]TemplateMatchDTO, 0)
+	}
+
+	type hit struct {
+		x, y  int
+		score float64
+	}
+	var hits []hit
+	tryAt := func(x, y int) {
+		if x < 0 || y < 0 || x > src.w-tpl.w || y > src.h-tpl.h {
+			return
+		}
+		if s := tpl.score(src, x, y); s >= threshold {
+			hits = append(hits, hit{x, y, s})
+		}
+	}
+
+	if tpl.w*tpl.h >= templateCoarseMinArea {
+		// Coarse search on half-size images, then refine around promising positions
+		coarseSrc, coarseTpl := src.halve(), newTemplate(tplPlanes.halve())
+		seen := make(map[[2]int]bool)
+		for y := 0; y <= coarseSrc.h-coarseTpl.h; y++ {
+			for x := 0; x <= coarseSrc.w-coarseTpl.w; x++ {
+				if coarseTpl.score(coarseSrc, x, y) < threshold-templateCoarseSlack {
+					continue
+				}
+				for dy := -1; dy <= 2; dy++ {
+					for dx := -1; dx <= 2; dx++ {
+						pos := [2]int{2*x + dx, 2*y + dy}
+						if !seen[pos] {
+							seen[pos] = true
+							tryAt(pos[0], pos[1])
+						}
+					}
+				}
+			}
+		}
+	} else {
+		for y := 0; y <= src.h-tpl.h; y++ {
+			for x := 0; x <= src.w-tpl.w; x++ {
+				tryAt(x, y)
+			}
+		}
+	}
+
+	// Non-maximum suppression: best first, drop matches overlapping an accepted one by half its area
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+	area := tpl.w * tpl.h
+	matches := make([]TemplateMatchDTO, 0)
+	for _, h := range hits {
+		if len(matches) >= maxMatch {
+			break
+		}
+		x, y := rect.Min.X+h.x, rect.Min.Y+h.y
+		box := image.Rect(x, y, x+tpl.w, y+tpl.h)
+		overlapped := false
+		for _, m := range matches {
+			inter := box.Intersect(image.Rect(m.X, m.Y, m.X+tpl.w, m.Y+tpl.h))
+			if inter.Dx()*inter.Dy()*2 > area {
+				overlapped = true
+				break
+			}
+		}
+		if !overlapped {
+			matches = append(matches, TemplateMatchDTO{x, y, x + tpl.w/2, y + tpl.h/2, h.score})
+		}
+	}
+	return matches
+}
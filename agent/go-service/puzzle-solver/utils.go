@@ -30,7 +30,7 @@ func matchTemplateAll(ctx *maa.Context, img image.Image, template string, roi []
 		nodeName: map[string]any{
 			"recognition": "TemplateMatch",
 			"template":    template,
-			"threshold":   0.7,
+			"threshold":   TEMPLATE_MATCH_THRESHOLD,
 			"roi":         roi,
 			"order_by":    "score",
 			"method":      5, // TM_CCOEFF_NORMED
@@ -80,6 +80,29 @@ func matchTemplateAll(ctx *maa.Context, img image.Image, template string, roi []
 
 /* ******** Colors ******** */
 
+// getAreaDiff calculates the mean absolute RGB channel difference of two images within rect
+func getAreaDiff(img1, img2 image.Image, rect image.Rectangle) float64 {
+	if img1 == nil || img2 == nil {
+		return math.Inf(1)
+	}
+	rect = rect.Intersect(img1.Bounds()).Intersect(img2.Bounds())
+	if rect.Empty() {
+		return math.Inf(1)
+	}
+
+	var sum float64
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			r1, g1, b1, _ := img1.At(x, y).RGBA()
+			r2, g2, b2, _ := img2.At(x, y).RGBA()
+			sum += math.Abs(float64(r1>>8)-float64(r2>>8)) +
+				math.Abs(float64(g1>>8)-float64(g2>>8)) +
+				math.Abs(float64(b1>>8)-float64(b2>>8))
+		}
+	}
+	return sum / float64(3*rect.Dx()*rect.Dy())
+}
+
 // getAreaVariance calculates the average standard deviation across RGB channels
 func getAreaVariance(img image.Image, rect image.Rectangle) float64 {
 	var sumR, sumG, sumB float64
@@ -149,6 +172,30 @@ func rgbToHSV(fr, fg, fb float64) (float64, float64, float64) {
 	return h, s, v
 }
 
+// hsvToRGB converts HSV (Hue[0, 360), Saturation[0, 1], Value[0, 1]) to a color
+func hsvToRGB(h, s, v float64) color.RGBA {
+	h = math.Mod(math.Mod(h, 360)+360, 360) / 60
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h, 2)-1))
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g, b = c, x, 0
+	case 1:
+		r, g, b = x, c, 0
+	case 2:
+		r, g, b = 0, c, x
+	case 3:
+		r, g, b = 0, x, c
+	case 4:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	m := v - c
+	return color.RGBA{uint8((r + m) * 255), uint8((g + m) * 255), uint8((b + m) * 255), 255}
+}
+
 // getAreaHSV calculates the Hue (Median), Saturation (Mean), and Value (Mean) of an area.
 // Hue is [0, 360), Saturation is [0, 1], Value is [0, 1].
 func getAreaHSV(img image.Image, rect image.Rectangle) (float64, float64, float64) {
@@ -298,16 +345,69 @@ func convertBoardCoordToLTCoord(bx, by int, totalW, totalH int) (int, int) {
 	return int(ltX), int(ltY)
 }
 
+// getThumbPanelRect returns the pixel area of one page of puzzle thumbnails
+func getThumbPanelRect() image.Rectangle {
+	return image.Rect(
+		int(PUZZLE_THUMB_START_X),
+		int(PUZZLE_THUMB_START_Y),
+		int(PUZZLE_THUMB_START_X+float64(PUZZLE_THUMB_MAX_COLS)*PUZZLE_THUMB_W),
+		int(PUZZLE_THUMB_START_Y+float64(PUZZLE_THUMB_MAX_ROWS)*PUZZLE_THUMB_H),
+	)
+}
+
+// getThumbSlot returns the panel page and slot of the puzzle with the given index
+func (bd *BoardDesc) getThumbSlot(puzzleIndex int) (int, int) {
+	if puzzleIndex >= 0 && puzzleIndex < len(bd.PuzzleList) {
+		pd := bd.PuzzleList[puzzleIndex]
+		return pd.Page, pd.Slot
+	}
+	pageSize := PUZZLE_THUMB_MAX_ROWS * PUZZLE_THUMB_MAX_COLS
+	return puzzleIndex / pageSize, puzzleIndex % pageSize
+}
+
+// getThumbCenter returns the pixel center of the puzzle thumbnail in the given slot of a page.
+// Thumbnails are analyzed in standard grid order (row by row, col by col).
+func getThumbCenter(slot int) (int, int) {
+	row := slot / int(PUZZLE_THUMB_MAX_COLS)
+	col := slot % int(PUZZLE_THUMB_MAX_COLS)
+	thumbX := PUZZLE_THUMB_START_X + float64(col)*PUZZLE_THUMB_W
+	thumbY := PUZZLE_THUMB_START_Y + float64(row)*PUZZLE_THUMB_H
+	return int(thumbX + PUZZLE_THUMB_W/2), int(thumbY + PUZZLE_THUMB_H/2)
+}
+
+// getBlockCenter returns the pixel center of the board block at grid index (bx, by)
+func getBlockCenter(bx, by int, totalW, totalH int) (int, int) {
+	ltX, ltY := convertBoardCoordToLTCoord(bx, by, totalW, totalH)
+	return int(float64(ltX) + BOARD_BLOCK_W/2), int(float64(ltY) + BOARD_BLOCK_H/2)
+}
+
+/* ******** Messages ******** */
+
+// showMessage shows a message to the user in MXU via a temporary node's focus
+func showMessage(ctx *maa.Context, text string) {
+	ctx.RunTask("PuzzleSolverShowMessage", map[string]any{
+		"PuzzleSolverShowMessage": map[string]any{
+			"recognition": "DirectHit",
+			"action":      "DoNothing",
+			"focus": map[string]any{
+				"Node.Action.Starting": text,
+			},
+		},
+	})
+}
+
 /* ******** Actions ******** */
 
-// ActionWrapper provides synchronized touch/key operations with built-in delays
+// ActionWrapper provides synchronized touch/key operations with built-in delays.
+// Touch positions are given in the work space and scaled to the screen.
 type ActionWrapper struct {
 	ctrl *maa.Controller
+	t    *coordTransform
 }
 
 // NewActionWrapper creates a new ActionWrapper from a context
 func NewActionWrapper(ctrl *maa.Controller) *ActionWrapper {
-	return &ActionWrapper{ctrl}
+	return &ActionWrapper{ctrl, getScreenTransform()}
 }
 
 // TouchUpSync releases touch contact and waits
@@ -319,6 +419,7 @@ func (aw *ActionWrapper) TouchUpSync(delayMillis int) {
 // TouchDownSync moves to position then touches down
 func (aw *ActionWrapper) TouchDownSync(contact, x, y int, delayMillis int) {
 	halfDelay := delayMillis / 2
+	x, y = aw.t.toScreen(x, y)
 	aw.ctrl.PostTouchMove(int32(contact), int32(x), int32(y), 1).Wait()
 	time.Sleep(time.Duration(halfDelay) * time.Millisecond)
 	aw.ctrl.PostTouchDown(int32(contact), int32(x), int32(y), 1).Wait()
@@ -327,10 +428,17 @@ func (aw *ActionWrapper) TouchDownSync(contact, x, y int, delayMillis int) {
 
 // TouchMoveSync moves touch contact to position and waits
 func (aw *ActionWrapper) TouchMoveSync(contact, x, y int, delayMillis int) {
+	x, y = aw.t.toScreen(x, y)
 	aw.ctrl.PostTouchMove(int32(contact), int32(x), int32(y), 1).Wait()
 	time.Sleep(time.Duration(delayMillis) * time.Millisecond)
 }
 
+// ScrollSync sends a mouse wheel scroll at the cursor and waits
+func (aw *ActionWrapper) ScrollSync(dx, dy int, delayMillis int) {
+	aw.ctrl.PostScroll(int32(dx), int32(dy)).Wait()
+	time.Sleep(time.Duration(delayMillis) * time.Millisecond)
+}
+
 // TypeKeySync sends a key press and waits
 func (aw *ActionWrapper) TypeKeySync(keyCode int, delayMillis int) {
 	aw.ctrl.PostClickKey(int32(keyCode)).Wait()
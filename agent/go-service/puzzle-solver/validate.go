@@ -0,0 +1,119 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import "fmt"
+
+// ValidateBoardDesc checks that the recognized board is self-consistent:
+// projections have the board's size and fit in it, each hue's projections agree with
+// its locked blocks plus puzzle blocks, and locked blocks stay under their projections.
+// It returns the failed checks and a confidence score: the fraction of checks passed,
+// halved as soon as one fails, so that an inconsistent board never passes PUZZLE_MIN_CONFIDENCE.
+func ValidateBoardDesc(bd *BoardDesc) (issues []string, confidence float64) {
+	checks := 0
+	check := func(ok bool, format string, args ...any) {
+		checks++
+		if !ok {
+			issues = append(issues, fmt.Sprintf(format, args...))
+		}
+	}
+	defer func() {
+		confidence = float64(checks-len(issues)) / float64(checks)
+		if len(issues) > 0 {
+			confidence /= 2
+		}
+	}()
+
+	check(bd.W > 0 && bd.H > 0, "invalid board size %dx%d", bd.W, bd.H)
+	check(len(bd.HueList) > 0, "no hues recognized")
+	check(len(bd.PuzzleList) > 0, "no puzzles recognized")
+	check(len(bd.ProjDescList) == len(bd.HueList), "%d projections for %d hues", len(bd.ProjDescList), len(bd.HueList))
+	check(len(bd.LockedBlockList) <= len(bd.HueList), "%d locked block groups for %d hues", len(bd.LockedBlockList), len(bd.HueList))
+	if len(issues) > 0 {
+		return
+	}
+
+	// Cells available per line, banned blocks excluded
+	colCells := make([]int, bd.W)
+	rowCells := make([]int, bd.H)
+	for x := range colCells {
+		colCells[x] = bd.H
+	}
+	for y := range rowCells {
+		rowCells[y] = bd.W
+	}
+	for _, bb := range bd.BannedBlockList {
+		if bb.Loc[0] >= 0 && bb.Loc[0] < bd.W && bb.Loc[1] >= 0 && bb.Loc[1] < bd.H {
+			colCells[bb.Loc[0]]--
+			rowCells[bb.Loc[1]]--
+		}
+	}
+
+	// Puzzle blocks per hue, assigned the same way as the solver does
+	hueMap := make(map[int]int, len(bd.HueList))
+	for i, h := range bd.HueList {
+		hueMap[h] = i
+	}
+	blocks := make([]int, len(bd.HueList))
+	for i, pd := range bd.PuzzleList {
+		pz := &Puzzle{}
+		pz.convertFromPuzzleDesc(i, pd, hueMap)
+		blocks[pz.Color] += len(pd.Blocks)
+	}
+
+	colTotal := make([]int, bd.W)
+	rowTotal := make([]int, bd.H)
+	for h, pd := range bd.ProjDescList {
+		hue := bd.HueList[h]
+		check(len(pd.XProjList) == bd.W, "hue %d has %d column projections for board width %d", hue, len(pd.XProjList), bd.W)
+		check(len(pd.YProjList) == bd.H, "hue %d has %d row projections for board height %d", hue, len(pd.YProjList), bd.H)
+		if len(pd.XProjList) != bd.W || len(pd.YProjList) != bd.H {
+			continue
+		}
+
+		// 1. Each projection fits in its line
+		sumX, sumY := 0, 0
+		for x, v := range pd.XProjList {
+			check(v <= colCells[x], "hue %d column %d expects %d, but the column has %d cells", hue, x, v, colCells[x])
+			colTotal[x] += v
+			sumX += v
+		}
+		for y, v := range pd.YProjList {
+			check(v <= rowCells[y], "hue %d row %d expects %d, but the row has %d cells", hue, y, v, rowCells[y])
+			rowTotal[y] += v
+			sumY += v
+		}
+
+		// 2. Locked blocks stay under the projections
+		lockedX := make([]int, bd.W)
+		lockedY := make([]int, bd.H)
+		locked := 0
+		if h < len(bd.LockedBlockList) {
+			for _, lb := range bd.LockedBlockList[h] {
+				if lb.Loc[0] >= 0 && lb.Loc[0] < bd.W && lb.Loc[1] >= 0 && lb.Loc[1] < bd.H {
+					lockedX[lb.Loc[0]]++
+					lockedY[lb.Loc[1]]++
+					locked++
+				}
+			}
+		}
+		for x, v := range pd.XProjList {
+			check(lockedX[x] <= v, "hue %d column %d expects %d, but has %d locked blocks", hue, x, v, lockedX[x])
+		}
+		for y, v := range pd.YProjList {
+			check(lockedY[y] <= v, "hue %d row %d expects %d, but has %d locked blocks", hue, y, v, lockedY[y])
+		}
+
+		// 3. Block totals agree
+		check(sumX == sumY, "hue %d column projections sum to %d, row projections to %d", hue, sumX, sumY)
+		check(sumX == locked+blocks[h], "hue %d projections expect %d blocks, got %d locked and %d from puzzles", hue, sumX, locked, blocks[h])
+	}
+
+	// 4. All hues together still fit in each line
+	for x, v := range colTotal {
+		check(v <= colCells[x], "column %d expects %d blocks in total, but has %d cells", x, v, colCells[x])
+	}
+	for y, v := range rowTotal {
+		check(v <= rowCells[y], "row %d expects %d blocks in total, but has %d cells", y, v, rowCells[y])
+	}
+	return
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2026 Harry Huang
+package puzzle
+
+import "testing"
+
+func TestValidateFixtureBoards(t *testing.T) {
+	for name, bd := range loadFixtureBoards(t) {
+		issues, confidence := ValidateBoardDesc(bd)
+		if len(issues) != 0 {
+			t.Errorf("%s: unexpected issues %v", name, issues)
+		}
+		if confidence != 1 {
+			t.Errorf("%s: confidence %v, want 1", name, confidence)
+		}
+	}
+}
+
+func TestValidateMisreadProjection(t *testing.T) {
+	bd := &BoardDesc{
+		W: 3,
+		H: 2,
+		ProjDescList: []ProjDesc{
+			{XProjList: []int{1, 3, 0}, YProjList: []int{2, 1}},
+		},
+		LockedBlockList: [][]*LockedBlockDesc{{{Loc: [2]int{0, 1}, Hue: 206}}},
+		PuzzleList: []*PuzzleDesc{
+			{Blocks: [][2]int{{0, 0}, {1, 0}}, Hue: 206},
+		},
+		HueList: []int{206},
+	}
+	issues, confidence := ValidateBoardDesc(bd)
+	if len(issues) == 0 {
+		t.Fatal("misread projection not reported")
+	}
+	if confidence <= 0 || confidence >= PUZZLE_MIN_CONFIDENCE {
+		t.Errorf("confidence %v, want within (0, %v)", confidence, PUZZLE_MIN_CONFIDENCE)
+	}
+}
+
+func TestValidateOneProjectionOff(t *testing.T) {
+	bd := loadFixtureBoards(t)["medium_5x5_2hue"]
+	bd.ProjDescList[0].XProjList[0]++
+	issues, confidence := ValidateBoardDesc(bd)
+	if len(issues) == 0 {
+		t.Fatal("projection off by one not reported")
+	}
+	if confidence >= PUZZLE_MIN_CONFIDENCE {
+		t.Errorf("confidence %v passes the gate %v with issues %v", confidence, PUZZLE_MIN_CONFIDENCE, issues)
+	}
+}